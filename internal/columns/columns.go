@@ -0,0 +1,164 @@
+package columns
+
+import (
+	"dtree/internal/format"
+	"dtree/internal/tree"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column identifies one metadata column shown beside node names
+type Column int
+
+// Columns in display order, matching `tree -pugsD --inodes`
+const (
+	Inode Column = iota
+	Permissions
+	Links
+	Owner
+	Group
+	Size
+	Time
+	numColumns
+)
+
+// Set is a bit set of enabled columns
+type Set uint
+
+// Long is the `ls -l`-style set toggled from the TUI
+const Long = Set(1<<Permissions | 1<<Owner | 1<<Size | 1<<Time)
+
+// With returns the set with c enabled
+func (s Set) With(c Column) Set {
+	return s | 1<<c
+}
+
+// Without returns the set with c disabled
+func (s Set) Without(c Column) Set {
+	return s &^ (1 << c)
+}
+
+// Has reports whether c is enabled
+func (s Set) Has(c Column) bool {
+	return s&(1<<c) != 0
+}
+
+// List returns the enabled columns in display order
+func (s Set) List() []Column {
+	var list []Column
+	for c := Column(0); c < numColumns; c++ {
+		if s.Has(c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// sixMonths is when timestamps switch from clock time to year, as in `ls -l`
+const sixMonths = 182 * 24 * time.Hour
+
+// Formatter renders column values for one frame of output
+type Formatter struct {
+	Columns  []Column
+	Now      time.Time
+	Human    bool // Sizes like "4.0K" instead of raw bytes
+	Relative bool // Times like "3h ago" instead of "Jan  2 15:04"
+}
+
+// Cells formats every enabled column of meta
+func (f Formatter) Cells(meta tree.Metadata) []string {
+	cells := make([]string, len(f.Columns))
+	for i, c := range f.Columns {
+		cells[i] = f.cell(c, meta)
+	}
+	return cells
+}
+
+func (f Formatter) cell(c Column, meta tree.Metadata) string {
+	switch c {
+	case Inode:
+		return strconv.FormatUint(meta.Inode, 10)
+	case Permissions:
+		return meta.Mode.String()
+	case Links:
+		return strconv.FormatUint(meta.Links, 10)
+	case Owner:
+		return meta.Owner
+	case Group:
+		return meta.Group
+	case Size:
+		if f.Human {
+			return format.Size(meta.Size)
+		}
+		return strconv.FormatInt(meta.Size, 10)
+	case Time:
+		if meta.ModTime.IsZero() {
+			return ""
+		}
+		if f.Relative {
+			return RelativeTime(meta.ModTime, f.Now)
+		}
+		return ClockTime(meta.ModTime, f.Now)
+	}
+	return ""
+}
+
+// Widths returns the widest cell of each column across rows
+func Widths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for len(widths) < len(row) {
+			widths = append(widths, 0)
+		}
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	return widths
+}
+
+// Join pads cells to their column widths, right-aligning numbers like `ls -l`
+func (f Formatter) Join(cells []string, widths []int) string {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		pad := strings.Repeat(" ", max(widths[i]-len([]rune(cell)), 0))
+		switch f.Columns[i] {
+		case Inode, Links, Size:
+			parts[i] = pad + cell
+		default:
+			parts[i] = cell + pad
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// RelativeTime renders how long before now t was, e.g. "5m ago"
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < 0:
+		return "future"
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	case d < sixMonths:
+		return fmt.Sprintf("%dmo ago", int(d/(30*24*time.Hour)))
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// ClockTime renders t like `ls -l`: clock time for recent files, the year otherwise
+func ClockTime(t, now time.Time) string {
+	if d := now.Sub(t); d > sixMonths || d < -sixMonths {
+		return t.Format("Jan _2  2006")
+	}
+	return t.Format("Jan _2 15:04")
+}
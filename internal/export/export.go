@@ -0,0 +1,123 @@
+package export
+
+import (
+	"dtree/internal/columns"
+	"dtree/internal/tree"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format selects how the tree is encoded
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+	FormatYAML
+	FormatXML
+)
+
+// Options controls what is written and in which format
+type Options struct {
+	Format  Format
+	Report  bool                  // Append directory and file counts
+	Include func(*tree.Node) bool // Optional filter for children, e.g. the TUI's view filters
+	Columns columns.Set           // Metadata columns printed before names in the text format
+}
+
+// ParseFormat converts a format name given on the command line
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text", "txt", "":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "xml":
+		return FormatXML, nil
+	default:
+		return FormatText, fmt.Errorf("unknown format %q (want text, json, yaml or xml)", name)
+	}
+}
+
+// FormatForPath picks a format from a file extension, defaulting to text
+func FormatForPath(path string) Format {
+	format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return FormatText
+	}
+	return format
+}
+
+// Write encodes the expanded part of the tree
+func Write(w io.Writer, root *tree.Node, opts Options) error {
+	switch opts.Format {
+	case FormatJSON:
+		return writeJSON(w, root, opts)
+	case FormatYAML:
+		return writeYAML(w, root, opts)
+	case FormatXML:
+		return writeXML(w, root, opts)
+	default:
+		return writeText(w, root, opts)
+	}
+}
+
+// entry is the format-independent snapshot of one node, shaped like `tree -J`/`tree -X` output
+type entry struct {
+	Type     string
+	Name     string
+	Path     string
+	Target   string // Symlink target, empty for other entries
+	Error    string // Why a directory could not be read
+	Size     int64
+	Time     time.Time
+	Contents []*entry
+}
+
+// snapshot converts the expanded nodes into entries and counts directories and files
+func snapshot(node *tree.Node, opts Options) (e *entry, dirs, files int) {
+	e = &entry{Type: "file", Name: node.Name, Path: node.Path, Size: node.Meta.Size, Time: node.Meta.ModTime}
+	if node.IsDir {
+		e.Type = "directory"
+	}
+	if node.Link != nil {
+		e.Type, e.Target = "link", node.Link.Target
+	}
+	e.Error = node.ErrorSummary()
+
+	for _, child := range visibleChildren(node, opts) {
+		childEntry, childDirs, childFiles := snapshot(child, opts)
+		e.Contents = append(e.Contents, childEntry)
+		if child.IsDir {
+			dirs++
+		} else {
+			files++
+		}
+		dirs += childDirs
+		files += childFiles
+	}
+	return e, dirs, files
+}
+
+// visibleChildren returns the children to write: none for collapsed directories
+func visibleChildren(node *tree.Node, opts Options) []*tree.Node {
+	if !node.IsExpanded {
+		return nil
+	}
+	if opts.Include == nil {
+		return node.Children
+	}
+
+	var children []*tree.Node
+	for _, child := range node.Children {
+		if opts.Include(child) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
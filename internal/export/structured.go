@@ -0,0 +1,172 @@
+package export
+
+import (
+	"bufio"
+	"dtree/internal/tree"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jsonEntry mirrors the objects emitted by `tree -J`
+type jsonEntry struct {
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	Target   string       `json:"target,omitempty"`
+	Path     string       `json:"path"`
+	Size     int64        `json:"size"`
+	Time     string       `json:"time,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Contents []*jsonEntry `json:"contents,omitempty"`
+}
+
+// jsonReport is the trailing summary object of `tree -J`
+type jsonReport struct {
+	Type        string `json:"type"`
+	Directories int    `json:"directories"`
+	Files       int    `json:"files"`
+}
+
+// xmlEntry mirrors the <directory>/<file> elements emitted by `tree -X`
+type xmlEntry struct {
+	XMLName  xml.Name
+	Name     string `xml:"name,attr"`
+	Target   string `xml:"target,attr,omitempty"`
+	Path     string `xml:"path,attr"`
+	Size     int64  `xml:"size,attr"`
+	Time     string `xml:"time,attr,omitempty"`
+	Error    string `xml:"error,omitempty"`
+	Contents []*xmlEntry
+}
+
+// xmlReport is the <report> element of `tree -X`
+type xmlReport struct {
+	XMLName     xml.Name `xml:"report"`
+	Directories int      `xml:"directories"`
+	Files       int      `xml:"files"`
+}
+
+// xmlTree is the document root
+type xmlTree struct {
+	XMLName xml.Name `xml:"tree"`
+	Root    *xmlEntry
+	Report  *xmlReport `xml:",omitempty"`
+}
+
+// formatTime renders modification times in RFC 3339, or nothing when unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// writeJSON implements the JSON format
+func writeJSON(w io.Writer, root *tree.Node, opts Options) error {
+	snap, dirs, files := snapshot(root, opts)
+
+	var convert func(e *entry) *jsonEntry
+	convert = func(e *entry) *jsonEntry {
+		j := &jsonEntry{Type: e.Type, Name: e.Name, Target: e.Target, Path: e.Path, Size: e.Size, Time: formatTime(e.Time), Error: e.Error}
+		for _, child := range e.Contents {
+			j.Contents = append(j.Contents, convert(child))
+		}
+		return j
+	}
+
+	doc := []any{convert(snap)}
+	if opts.Report {
+		doc = append(doc, jsonReport{Type: "report", Directories: dirs, Files: files})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// writeXML implements the XML format
+func writeXML(w io.Writer, root *tree.Node, opts Options) error {
+	snap, dirs, files := snapshot(root, opts)
+
+	var convert func(e *entry) *xmlEntry
+	convert = func(e *entry) *xmlEntry {
+		x := &xmlEntry{
+			XMLName: xml.Name{Local: e.Type},
+			Name:    e.Name,
+			Target:  e.Target,
+			Path:    e.Path,
+			Size:    e.Size,
+			Time:    formatTime(e.Time),
+			Error:   e.Error,
+		}
+		for _, child := range e.Contents {
+			x.Contents = append(x.Contents, convert(child))
+		}
+		return x
+	}
+
+	doc := xmlTree{Root: convert(snap)}
+	if opts.Report {
+		doc.Report = &xmlReport{Directories: dirs, Files: files}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeYAML implements the YAML format as a list shaped like the JSON output
+func writeYAML(w io.Writer, root *tree.Node, opts Options) error {
+	snap, dirs, files := snapshot(root, opts)
+
+	bw := bufio.NewWriter(w)
+	writeYAMLEntry(bw, snap, "")
+	if opts.Report {
+		fmt.Fprintf(bw, "- type: report\n  directories: %d\n  files: %d\n", dirs, files)
+	}
+	return bw.Flush()
+}
+
+// writeYAMLEntry writes one list item and its contents at the given indentation
+func writeYAMLEntry(w io.Writer, e *entry, indent string) {
+	fmt.Fprintf(w, "%s- type: %s\n", indent, e.Type)
+	fields := indent + "  "
+	fmt.Fprintf(w, "%sname: %s\n", fields, yamlString(e.Name))
+	if e.Target != "" {
+		fmt.Fprintf(w, "%starget: %s\n", fields, yamlString(e.Target))
+	}
+	fmt.Fprintf(w, "%spath: %s\n", fields, yamlString(e.Path))
+	fmt.Fprintf(w, "%ssize: %d\n", fields, e.Size)
+	if t := formatTime(e.Time); t != "" {
+		fmt.Fprintf(w, "%stime: %s\n", fields, t)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(w, "%serror: %s\n", fields, yamlString(e.Error))
+	}
+	if len(e.Contents) > 0 {
+		fmt.Fprintf(w, "%scontents:\n", fields)
+		for _, child := range e.Contents {
+			writeYAMLEntry(w, child, fields+"  ")
+		}
+	}
+}
+
+// yamlString quotes a scalar; JSON string syntax is valid double-quoted YAML
+func yamlString(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bufio"
+	"dtree/internal/columns"
+	"dtree/internal/tree"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Text writes the expanded part of the tree using the classic `tree` layout,
+// optionally followed by a "N directories, M files" report
+func Text(w io.Writer, root *tree.Node, report bool) error {
+	return writeText(w, root, Options{Report: report})
+}
+
+// textWriter carries the state shared by every line of a text listing
+type textWriter struct {
+	w         io.Writer
+	opts      Options
+	formatter columns.Formatter
+	widths    []int
+}
+
+// writeText implements the text format
+func writeText(w io.Writer, root *tree.Node, opts Options) error {
+	bw := bufio.NewWriter(w)
+	if root.Err != nil {
+		fmt.Fprintf(bw, "%s  [%s]\n", root.Path, root.ErrorSummary())
+	} else {
+		fmt.Fprintln(bw, root.Path)
+	}
+
+	tw := &textWriter{w: bw, opts: opts}
+	if opts.Columns != 0 {
+		tw.formatter = columns.Formatter{Columns: opts.Columns.List(), Now: time.Now()}
+		var rows [][]string
+		tw.collectCells(root, &rows)
+		tw.widths = columns.Widths(rows)
+	}
+
+	dirs, files := tw.writeChildren(root, "")
+	if opts.Report {
+		fmt.Fprintf(bw, "\n%s, %s\n", plural(dirs, "directory", "directories"), plural(files, "file", "files"))
+	}
+	return bw.Flush()
+}
+
+// collectCells formats the metadata of every node that will be printed, to size the columns
+func (tw *textWriter) collectCells(node *tree.Node, rows *[][]string) {
+	for _, child := range visibleChildren(node, tw.opts) {
+		*rows = append(*rows, tw.formatter.Cells(child.Meta))
+		tw.collectCells(child, rows)
+	}
+}
+
+// writeChildren prints the children of an expanded node and counts what it printed
+func (tw *textWriter) writeChildren(node *tree.Node, indent string) (dirs, files int) {
+	children := visibleChildren(node, tw.opts)
+	for i, child := range children {
+		connector, childIndent := tree.BranchPrefix, tree.PipeIndent
+		if i == len(children)-1 {
+			connector, childIndent = tree.LastPrefix, tree.BlankIndent
+		}
+
+		// Metadata goes in brackets before the name, like `tree -pugsD`
+		meta := ""
+		if tw.widths != nil {
+			meta = "[" + tw.formatter.Join(tw.formatter.Cells(child.Meta), tw.widths) + "]  "
+		}
+		name := child.Name
+		if child.Link != nil {
+			name += " -> " + child.Link.Target
+		}
+		if child.Err != nil {
+			name += "  [" + child.ErrorSummary() + "]"
+		}
+		fmt.Fprintf(tw.w, "%s%s%s%s\n", indent, connector, meta, name)
+
+		if child.IsDir {
+			dirs++
+			subDirs, subFiles := tw.writeChildren(child, indent+childIndent)
+			dirs += subDirs
+			files += subFiles
+		} else {
+			files++
+		}
+	}
+	return dirs, files
+}
+
+// plural formats a count with the singular or plural noun
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
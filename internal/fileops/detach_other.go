@@ -0,0 +1,8 @@
+//go:build !unix
+
+package fileops
+
+import "os/exec"
+
+// detach is a no-op where processes do not share the terminal's lifetime
+func detach(cmd *exec.Cmd) {}
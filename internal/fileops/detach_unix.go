@@ -0,0 +1,13 @@
+//go:build unix
+
+package fileops
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in its own process group so it outlives the terminal session
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
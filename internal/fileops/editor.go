@@ -0,0 +1,43 @@
+package fileops
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// EditorCommand builds the command that edits path in $VISUAL or $EDITOR,
+// falling back to vi (notepad on Windows). A positive line is passed the way
+// the editor expects it: "+42 path" for vi, nano and emacs, "path:42" for
+// editors that take a position in the file argument.
+func EditorCommand(path string, line int) *exec.Cmd {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+		if runtime.GOOS == "windows" {
+			editor = []string{"notepad"}
+		}
+	}
+
+	args := editor[1:]
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(editor[0])), ".exe")
+	switch {
+	case line <= 0:
+		args = append(args, path)
+	case name == "code" || name == "code-insiders" || name == "codium":
+		args = append(args, "--goto", path+":"+strconv.Itoa(line))
+	case name == "subl" || name == "hx" || name == "helix" || name == "zed":
+		args = append(args, path+":"+strconv.Itoa(line))
+	case name == "notepad":
+		args = append(args, path) // No way to pass a line
+	default:
+		args = append(args, "+"+strconv.Itoa(line), path)
+	}
+	return exec.Command(editor[0], args...)
+}
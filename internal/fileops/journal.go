@@ -0,0 +1,464 @@
+package fileops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxJournalEntries bounds how many operations can be undone
+const maxJournalEntries = 200
+
+// lockTimeout bounds how long a change waits for another instance to release
+// the journal; a lock older than staleLockAge was left behind by a crash
+const (
+	lockTimeout  = 5 * time.Second
+	staleLockAge = 30 * time.Second
+)
+
+// Errors returned when the history is exhausted
+var (
+	ErrNothingToUndo  = errors.New("nothing to undo")
+	ErrNothingToRedo  = errors.New("nothing to redo")
+	ErrStaleOperation = errors.New("no longer exists, dropped from the history")
+)
+
+// OpKind identifies a file operation in the journal
+type OpKind string
+
+const (
+	OpCreate OpKind = "create" // Path was created (IsDir for directories), with missing parents from Parent
+	OpMove   OpKind = "move"   // Path was renamed or moved to Target
+	OpCopy   OpKind = "copy"   // Path was copied to Target
+	OpTrash  OpKind = "trash"  // Path was moved to the trash in TrashDir as TrashName
+	OpGroup  OpKind = "group"  // The operations in Ops were done together, e.g. on marked entries
+)
+
+// Operation is one undoable change made through dtree
+type Operation struct {
+	Kind      OpKind      `json:"kind"`
+	Path      string      `json:"path"`
+	Target    string      `json:"target,omitempty"`
+	IsDir     bool        `json:"dir,omitempty"`
+	Parent    string      `json:"parent,omitempty"` // Topmost directory created along with Path
+	TrashDir  string      `json:"trashDir,omitempty"`
+	TrashName string      `json:"trashName,omitempty"`
+	Ops       []Operation `json:"ops,omitempty"` // Members of a group, in the order they were done
+	Time      time.Time   `json:"time"`
+}
+
+// String describes the operation, e.g. "move a.txt to b.txt"
+func (op Operation) String() string {
+	switch op.Kind {
+	case OpGroup:
+		return fmt.Sprintf("%s of %d entries", op.Ops[0].Kind, len(op.Ops))
+	case OpMove, OpCopy:
+		return fmt.Sprintf("%s %s to %s", op.Kind, op.Path, op.Target)
+	default:
+		return fmt.Sprintf("%s %s", op.Kind, op.Path)
+	}
+}
+
+// TrashOperation records that item was moved to trash
+func TrashOperation(trash Trash, item TrashItem) Operation {
+	return Operation{Kind: OpTrash, Path: item.Original, IsDir: item.IsDir, TrashDir: trash.Dir, TrashName: item.Name, Time: item.DeletedAt}
+}
+
+// GroupOperation combines operations done together so they are undone and
+// redone as one; a single operation is returned as is
+func GroupOperation(ops []Operation) Operation {
+	if len(ops) == 1 {
+		return ops[0]
+	}
+	return Operation{Kind: OpGroup, Ops: ops, Time: time.Now()}
+}
+
+// Journal is the undo and redo history of file operations, stored as JSON so
+// it survives restarts. The file is re-read under a lock before every change,
+// so several dtree instances share one history. Undoing a create or copy
+// moves the result to Trash rather than deleting it.
+type Journal struct {
+	Path  string
+	Trash Trash
+	Root  string // Undo and Redo only consider operations touching paths below Root, if set
+}
+
+// journalFile is the on-disk form of the journal
+type journalFile struct {
+	Done   []Operation `json:"done"`
+	Undone []Operation `json:"undone"`
+}
+
+// DefaultJournalPath returns $XDG_STATE_HOME/dtree/journal.json, or ~/.local/state/dtree/journal.json
+func DefaultJournalPath() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "dtree", "journal.json"), nil
+}
+
+// Record adds a completed operation; anything undone before below Root can no longer be redone
+func (j Journal) Record(op Operation) error {
+	if op.Time.IsZero() {
+		op.Time = time.Now()
+	}
+	unlock, err := j.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := j.load()
+	if err != nil {
+		return err
+	}
+	file.Done = append(file.Done, op)
+	if len(file.Done) > maxJournalEntries {
+		file.Done = file.Done[len(file.Done)-maxJournalEntries:]
+	}
+	file.Undone = slices.DeleteFunc(file.Undone, j.inScope)
+	return j.save(file)
+}
+
+// Undo reverts the most recent operation below Root and returns it. When reverting fails
+// the journal is left unchanged so the undo can be retried, unless what the
+// operation produced is gone: then the entry is dropped with ErrStaleOperation
+// so older operations can still be undone. A group is reverted newest member
+// first, skipping members that are gone; when one fails, the members not
+// reverted yet stay in the history.
+func (j Journal) Undo() (Operation, error) {
+	unlock, err := j.lock()
+	if err != nil {
+		return Operation{}, err
+	}
+	defer unlock()
+	file, err := j.load()
+	if err != nil {
+		return Operation{}, err
+	}
+	i := j.latest(file.Done)
+	if i < 0 {
+		return Operation{}, ErrNothingToUndo
+	}
+	op := file.Done[i]
+	file.Done = slices.Delete(file.Done, i, i+1)
+	if source := j.missingSource(op); source != "" {
+		if err := j.save(file); err != nil {
+			return op, err
+		}
+		return op, fmt.Errorf("%s %w", source, ErrStaleOperation)
+	}
+
+	var reverted []Operation
+	members := op.members()
+	for len(members) > 0 {
+		last := members[len(members)-1]
+		if j.missingSource(last) == "" {
+			if err = j.revert(last); err != nil {
+				break
+			}
+			reverted = append([]Operation{last}, reverted...)
+		}
+		members = members[:len(members)-1]
+	}
+	if len(members) == len(op.members()) {
+		return op, err
+	}
+	if len(members) > 0 {
+		file.Done = append(file.Done, op.withMembers(members))
+	}
+	if len(reverted) > 0 {
+		file.Undone = append(file.Undone, op.withMembers(reverted))
+	}
+	if saveErr := j.save(file); err == nil {
+		err = saveErr
+	}
+	return op, err
+}
+
+// Redo repeats the most recently undone operation below Root and returns it. When a
+// member of a group fails, the members done before it count as redone.
+func (j Journal) Redo() (Operation, error) {
+	unlock, err := j.lock()
+	if err != nil {
+		return Operation{}, err
+	}
+	defer unlock()
+	file, err := j.load()
+	if err != nil {
+		return Operation{}, err
+	}
+	i := j.latest(file.Undone)
+	if i < 0 {
+		return Operation{}, ErrNothingToRedo
+	}
+	op := file.Undone[i]
+	var applied []Operation
+	members := op.members()
+	for len(members) > 0 {
+		var done Operation
+		if done, err = j.apply(members[0]); err != nil {
+			break
+		}
+		applied = append(applied, done)
+		members = members[1:]
+	}
+	if len(applied) == 0 {
+		return op, err
+	}
+
+	file.Undone = slices.Delete(file.Undone, i, i+1)
+	if len(members) > 0 {
+		file.Undone = append(file.Undone, op.withMembers(members))
+	}
+	op = op.withMembers(applied)
+	file.Done = append(file.Done, op)
+	if saveErr := j.save(file); err == nil {
+		err = saveErr
+	}
+	return op, err
+}
+
+// ForgetTrashed drops the trash operation that produced item, after the item
+// left the trash some other way, e.g. restored or purged from the trash view
+func (j Journal) ForgetTrashed(trash Trash, item TrashItem) error {
+	unlock, err := j.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := j.load()
+	if err != nil {
+		return err
+	}
+	forgotten := false
+	kept := file.Done[:0]
+	for _, op := range file.Done {
+		var members []Operation
+		for _, member := range op.members() {
+			if member.Kind == OpTrash && member.TrashDir == trash.Dir && member.TrashName == item.Name {
+				forgotten = true
+				continue
+			}
+			members = append(members, member)
+		}
+		if len(members) > 0 {
+			kept = append(kept, op.withMembers(members))
+		}
+	}
+	if !forgotten {
+		return nil
+	}
+	file.Done = kept
+	return j.save(file)
+}
+
+// latest returns the index of the newest operation in ops below Root, or -1
+func (j Journal) latest(ops []Operation) int {
+	for i := len(ops) - 1; i >= 0; i-- {
+		if j.inScope(ops[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inScope reports whether op, or a member of it, touches a path below Root
+func (j Journal) inScope(op Operation) bool {
+	if j.Root == "" {
+		return true
+	}
+	root, err := filepath.Abs(j.Root)
+	if err != nil {
+		return true
+	}
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	for _, member := range op.members() {
+		for _, path := range []string{member.Path, member.Target} {
+			if path == "" {
+				continue
+			}
+			if abs, err := filepath.Abs(path); err == nil && (abs == root || strings.HasPrefix(abs, prefix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// members returns the operations of a group, or op itself
+func (op Operation) members() []Operation {
+	if op.Kind == OpGroup {
+		return op.Ops
+	}
+	return []Operation{op}
+}
+
+// withMembers returns op with its members replaced; a single operation is its only member
+func (op Operation) withMembers(members []Operation) Operation {
+	if op.Kind != OpGroup {
+		return members[0]
+	}
+	op.Ops = members
+	return op
+}
+
+// missingSource returns the path reverting op starts from if it no longer
+// exists; for a group, only when that holds for every member
+func (j Journal) missingSource(op Operation) string {
+	if op.Kind == OpGroup {
+		missing := ""
+		for _, member := range op.Ops {
+			if missing = j.missingSource(member); missing == "" {
+				return ""
+			}
+		}
+		return missing
+	}
+	source := j.revertSource(op)
+	if source == "" {
+		return ""
+	}
+	if _, err := os.Lstat(source); errors.Is(err, fs.ErrNotExist) {
+		return source
+	}
+	return ""
+}
+
+// revertSource returns the path that reverting op starts from
+func (j Journal) revertSource(op Operation) string {
+	switch op.Kind {
+	case OpCreate:
+		if op.Parent != "" {
+			return op.Parent
+		}
+		return op.Path
+	case OpMove, OpCopy:
+		return op.Target
+	case OpTrash:
+		trash := Trash{Dir: op.TrashDir}
+		return trash.filePath(TrashItem{Name: op.TrashName})
+	}
+	return ""
+}
+
+// revert applies the inverse of op
+func (j Journal) revert(op Operation) error {
+	switch op.Kind {
+	case OpCreate:
+		created := op.Path
+		if op.Parent != "" {
+			created = op.Parent
+		}
+		_, err := j.Trash.Put(created)
+		return err
+	case OpMove:
+		return Move(op.Target, op.Path)
+	case OpCopy:
+		_, err := j.Trash.Put(op.Target)
+		return err
+	case OpTrash:
+		return Trash{Dir: op.TrashDir}.Restore(TrashItem{Name: op.TrashName, Original: op.Path})
+	}
+	return fmt.Errorf("unknown operation %q", op.Kind)
+}
+
+// apply performs op again, returning it updated where the result differs (the trash name)
+func (j Journal) apply(op Operation) (Operation, error) {
+	switch op.Kind {
+	case OpCreate:
+		if op.IsDir {
+			return op, CreateDir(op.Path)
+		}
+		return op, CreateFile(op.Path)
+	case OpMove:
+		return op, Move(op.Path, op.Target)
+	case OpCopy:
+		return op, Copy(op.Path, op.Target)
+	case OpTrash:
+		trash := Trash{Dir: op.TrashDir}
+		item, err := trash.Put(op.Path)
+		if err != nil {
+			return op, err
+		}
+		return TrashOperation(trash, item), nil
+	}
+	return op, fmt.Errorf("unknown operation %q", op.Kind)
+}
+
+// load reads the journal; a missing file is an empty history
+func (j Journal) load() (journalFile, error) {
+	var file journalFile
+	data, err := os.ReadFile(j.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("reading %s: %w", j.Path, err)
+	}
+	return file, nil
+}
+
+// save replaces the journal file atomically, through a temporary file of its own
+func (j Journal) save(file journalFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.Path), filepath.Base(j.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// lock creates the lock file next to the journal exclusively, waiting while
+// another instance holds it, and returns the function that releases it
+func (j Journal) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0700); err != nil {
+		return nil, err
+	}
+	path := j.Path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("history is locked by another dtree (remove %s if none is running)", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
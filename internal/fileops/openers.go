@@ -0,0 +1,347 @@
+package fileops
+
+import (
+	"bufio"
+	"dtree/internal/tree"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// OpenMode decides how an opener command runs
+type OpenMode string
+
+const (
+	OpenDetached   OpenMode = "detached"   // Started in the background while dtree keeps running
+	OpenForeground OpenMode = "foreground" // Takes over the terminal until it exits, like an editor
+	OpenPager      OpenMode = "pager"      // Output is shown in $PAGER
+)
+
+// OpenRule maps files selected by a pattern to the command that opens them
+type OpenRule struct {
+	Pattern string // Glob, re:regex or mime:type/subtype as written in the config
+	Mode    OpenMode
+	Command []string // Program and arguments with {path}, {dir}, {name} and {line} placeholders
+
+	patterns  tree.PatternSet
+	mimeType  string                       // Set for mime: patterns, may end in /*
+	wait      bool                         // Run to completion instead of in the background
+	translate func(string) (string, error) // Converts the path for the program, e.g. for Windows programs under WSL
+}
+
+// Openers is an ordered rule table where the first matching rule wins.
+// The platform's default opener is used for files no rule matches.
+type Openers []OpenRule
+
+// TerminalCommand is a command that runs on the terminal while the TUI is suspended
+type TerminalCommand interface {
+	Run() error
+	SetStdin(io.Reader)
+	SetStdout(io.Writer)
+	SetStderr(io.Writer)
+}
+
+// OpenFile opens a file with the default system application
+func OpenFile(filePath string) error {
+	rule, err := Openers(nil).Rule(filepath.Dir(filePath), filePath)
+	if err != nil {
+		return err
+	}
+	return rule.Start(filePath, 0)
+}
+
+// FormatOpenError formats an error message for file opening failures
+func FormatOpenError(filePath string, err error) string {
+	return fmt.Sprintf("Error opening %s: %v", filepath.Base(filePath), err)
+}
+
+// FormatPlatformError formats an error message for unsupported platforms
+func FormatPlatformError() string {
+	return fmt.Sprintf("Error: Unsupported platform %s", runtime.GOOS)
+}
+
+// DefaultOpenersPath returns $XDG_CONFIG_HOME/dtree/openers, or ~/.config/dtree/openers
+func DefaultOpenersPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "dtree", "openers"), nil
+}
+
+// LoadOpeners reads a rule file; a missing file means no rules
+func LoadOpeners(path string) (Openers, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseOpeners(file, path)
+}
+
+// ParseOpeners reads one rule per line as "pattern mode command...", e.g.
+//
+//	*.log        pager       cat {path}
+//	mime:image/* detached    feh {path}
+//
+// Blank lines and lines starting with # are skipped. name labels errors.
+func ParseOpeners(r io.Reader, name string) (Openers, error) {
+	var rules Openers
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseOpenRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, number, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// parseOpenRule parses a single rule line
+func parseOpenRule(line string) (OpenRule, error) {
+	fields, err := splitCommand(line)
+	if err != nil {
+		return OpenRule{}, err
+	}
+	if len(fields) < 3 {
+		return OpenRule{}, fmt.Errorf("want \"pattern mode command\", got %q", line)
+	}
+
+	rule := OpenRule{Pattern: fields[0], Mode: OpenMode(fields[1]), Command: fields[2:]}
+	switch rule.Mode {
+	case OpenDetached, OpenForeground, OpenPager:
+	default:
+		return OpenRule{}, fmt.Errorf("unknown mode %q (want detached, foreground or pager)", fields[1])
+	}
+	if mimeType, ok := strings.CutPrefix(rule.Pattern, "mime:"); ok {
+		rule.mimeType = mimeType
+		return rule, nil
+	}
+	rule.patterns, err = tree.ParsePatterns([]string{rule.Pattern})
+	return rule, err
+}
+
+// Rule returns the first rule matching filePath, falling back to the system opener.
+// Patterns containing a slash are matched against the path relative to root, like -P.
+func (o Openers) Rule(root, filePath string) (OpenRule, error) {
+	return o.RuleFor(CurrentPlatform(), root, filePath)
+}
+
+// RuleFor is Rule with the system opener of platform
+func (o Openers) RuleFor(platform Platform, root, filePath string) (OpenRule, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return OpenRule{}, err
+	}
+	system, systemErr := SystemOpeners(platform)
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil {
+		rel = filePath
+	}
+	detected := ""
+	for _, rule := range append(o[:len(o):len(o)], system...) {
+		if rule.mimeType != "" && detected == "" {
+			detected = detectMIME(filePath, info.IsDir())
+		}
+		if rule.matches(filePath, filepath.ToSlash(rel), detected) {
+			return rule, nil
+		}
+	}
+	return OpenRule{}, systemErr
+}
+
+// matches reports whether the rule selects filePath, at rel below the tree root, with the given MIME type
+func (r OpenRule) matches(filePath, rel, mimeType string) bool {
+	if r.mimeType != "" {
+		if prefix, ok := strings.CutSuffix(r.mimeType, "*"); ok {
+			return strings.HasPrefix(mimeType, prefix)
+		}
+		return mimeType == r.mimeType
+	}
+	return r.patterns.Match(filepath.Base(filePath), rel)
+}
+
+// detectMIME guesses the type from the extension, then from the content.
+// Directories are inode/directory as in the freedesktop.org shared MIME database.
+func detectMIME(filePath string, isDir bool) string {
+	if isDir {
+		return "inode/directory"
+	}
+	if byExt := mime.TypeByExtension(filepath.Ext(filePath)); byExt != "" {
+		mediaType, _, _ := strings.Cut(byExt, ";")
+		return mediaType
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	mediaType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return mediaType
+}
+
+// Cmd builds the rule's command for filePath, filling in the placeholders
+func (r OpenRule) Cmd(filePath string, line int) *exec.Cmd {
+	if line <= 0 {
+		line = 1
+	}
+	replacer := strings.NewReplacer(
+		"{path}", filePath,
+		"{dir}", filepath.Dir(filePath),
+		"{name}", filepath.Base(filePath),
+		"{line}", strconv.Itoa(line),
+	)
+	args := make([]string, len(r.Command))
+	for i, arg := range r.Command {
+		args[i] = replacer.Replace(arg)
+	}
+	return exec.Command(args[0], args[1:]...)
+}
+
+// Start runs a detached rule in the background, in its own process group so
+// it keeps running after dtree exits. Most system openers are waited for
+// instead, since they hand the file to another application and return at once.
+func (r OpenRule) Start(filePath string, line int) error {
+	if r.translate != nil {
+		translated, err := r.translate(filePath)
+		if err != nil {
+			return fmt.Errorf("translating %s: %w", filePath, err)
+		}
+		filePath = translated
+	}
+	cmd := r.Cmd(filePath, line)
+	if r.wait {
+		return cmd.Run()
+	}
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// TerminalCommand builds the command for foreground and pager rules
+func (r OpenRule) TerminalCommand(filePath string, line int) TerminalCommand {
+	cmd := r.Cmd(filePath, line)
+	if r.Mode != OpenPager {
+		return &terminalCmd{cmd}
+	}
+	return &pagedCmd{cmd: cmd, pager: pagerCommand()}
+}
+
+// pagerCommand returns $PAGER, or less -R (more on Windows)
+func pagerCommand() *exec.Cmd {
+	pager, _ := splitCommand(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+		if runtime.GOOS == "windows" {
+			pager = []string{"more"}
+		}
+	}
+	return exec.Command(pager[0], pager[1:]...)
+}
+
+// terminalCmd adapts an exec.Cmd to TerminalCommand
+type terminalCmd struct {
+	cmd *exec.Cmd
+}
+
+func (c *terminalCmd) Run() error            { return c.cmd.Run() }
+func (c *terminalCmd) SetStdin(r io.Reader)  { c.cmd.Stdin = r }
+func (c *terminalCmd) SetStdout(w io.Writer) { c.cmd.Stdout = w }
+func (c *terminalCmd) SetStderr(w io.Writer) { c.cmd.Stderr = w }
+
+// pagedCmd pipes the output of cmd into the pager, which owns the terminal
+type pagedCmd struct {
+	cmd, pager *exec.Cmd
+}
+
+func (c *pagedCmd) SetStdin(r io.Reader)  { c.pager.Stdin = r }
+func (c *pagedCmd) SetStdout(w io.Writer) { c.pager.Stdout = w }
+func (c *pagedCmd) SetStderr(w io.Writer) { c.cmd.Stderr, c.pager.Stderr = w, w }
+
+// Run starts both commands and waits for the pager; quitting the pager early
+// stops the command with a broken pipe, which is not an error
+func (c *pagedCmd) Run() error {
+	out, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	c.pager.Stdin = out
+	if err := c.cmd.Start(); err != nil {
+		return err
+	}
+	pagerErr := c.pager.Run()
+	if pagerErr != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+	return pagerErr
+}
+
+// splitCommand splits a line into words like a shell, honoring single and
+// double quotes and backslash escapes outside quotes
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote == 0:
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,148 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// CreateFile creates an empty file and any missing parents, failing if path already exists
+func CreateFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// CreateDir creates a directory and any missing parents, failing if path already exists
+func CreateDir(path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrExist}
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+// CheckName returns an error unless name is a single path element
+func CheckName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return nil
+}
+
+// Rename gives path a new name in the same directory and returns the new path
+func Rename(path, name string) (string, error) {
+	if err := CheckName(name); err != nil {
+		return "", err
+	}
+	target := filepath.Join(filepath.Dir(path), name)
+	return target, Move(path, target)
+}
+
+// Move moves src to dst, which must not exist yet. Across filesystems the
+// contents are copied and src is removed once the copy succeeded.
+func Move(src, dst string) error {
+	if err := checkTarget(src, dst); err != nil {
+		return err
+	}
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := Copy(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// Copy copies src to dst, which must not exist yet. Directories are copied
+// recursively; symlinks are copied as links rather than followed.
+func Copy(src, dst string) error {
+	if err := checkTarget(src, dst); err != nil {
+		return err
+	}
+	return copyEntry(src, dst)
+}
+
+// Delete removes path and, for directories, everything below it
+func Delete(path string) error {
+	if _, err := os.Lstat(path); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// checkTarget refuses to overwrite dst or to put a directory inside itself
+func checkTarget(src, dst string) error {
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return &fs.PathError{Op: "copy", Path: dst, Err: fs.ErrExist}
+	}
+	if rel, err := filepath.Rel(src, dst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("cannot copy or move %s into itself", src)
+	}
+	return nil
+}
+
+// copyEntry copies one file, link or directory tree, keeping permission bits
+func copyEntry(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyEntry(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+}
+
+// copyFile copies the contents of a regular file
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
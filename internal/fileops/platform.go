@@ -0,0 +1,81 @@
+package fileops
+
+import (
+	"dtree/internal/tree"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Platform describes the system dtree runs on, deciding the default opener.
+// Tests construct it directly to check other platforms.
+type Platform struct {
+	OS       string                            // runtime.GOOS value
+	WSL      bool                              // Linux running under the Windows Subsystem for Linux
+	LookPath func(file string) (string, error) // Finds programs, exec.LookPath by default
+}
+
+// CurrentPlatform returns the platform dtree is running on, detected once
+var CurrentPlatform = sync.OnceValue(func() Platform {
+	return Platform{OS: runtime.GOOS, WSL: runtime.GOOS == "linux" && detectWSL(), LookPath: exec.LookPath}
+})
+
+// detectWSL recognizes WSL by its environment or the Microsoft kernel release
+func detectWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// SystemOpeners returns the catch-all rule that hands files to the platform's
+// default application:
+//
+//   - macOS: open
+//   - Windows: rundll32 url.dll,FileProtocolHandler
+//   - WSL: wslview, or explorer.exe with the path translated by wslpath
+//   - Linux and the BSDs: xdg-open
+func SystemOpeners(p Platform) (Openers, error) {
+	lookPath := p.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	has := func(program string) bool {
+		_, err := lookPath(program)
+		return err == nil
+	}
+
+	// Most openers return as soon as the application started, so waiting for
+	// them reports failures; explorer.exe exits non-zero even on success
+	rule := OpenRule{Pattern: "*", Mode: OpenDetached, wait: true}
+	switch {
+	case p.OS == "darwin":
+		rule.Command = []string{"open", "{path}"}
+	case p.OS == "windows":
+		// Not cmd /c start, which would interpret &, | and % in file names
+		rule.Command = []string{"rundll32", "url.dll,FileProtocolHandler", "{path}"}
+	case p.WSL && has("wslview"):
+		rule.Command = []string{"wslview", "{path}"}
+	case p.WSL && has("explorer.exe") && has("wslpath"):
+		rule.Command = []string{"explorer.exe", "{path}"}
+		rule.wait = false
+		rule.translate = func(path string) (string, error) {
+			out, err := exec.Command("wslpath", "-w", path).Output()
+			return strings.TrimSpace(string(out)), err
+		}
+	case p.OS == "linux" || p.OS == "freebsd" || p.OS == "openbsd" || p.OS == "netbsd" || p.OS == "dragonfly":
+		if !has("xdg-open") {
+			return nil, errors.New("xdg-open not found; install xdg-utils or add a rule to the openers file")
+		}
+		rule.Command = []string{"xdg-open", "{path}"}
+	default:
+		return nil, fmt.Errorf("unsupported platform: %s", p.OS)
+	}
+	rule.patterns, _ = tree.ParsePatterns([]string{"*"})
+	return Openers{rule}, nil
+}
@@ -0,0 +1,223 @@
+package fileops
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trashTimeFormat is the DeletionDate format of .trashinfo files (local time)
+const trashTimeFormat = "2006-01-02T15:04:05"
+
+// Trash is a trash directory following the freedesktop.org Trash specification:
+// trashed entries live in files/ with a matching .trashinfo file in info/
+type Trash struct {
+	Dir string
+}
+
+// TrashItem is one entry in the trash
+type TrashItem struct {
+	Name      string    // Name inside files/, unique within the trash
+	Original  string    // Absolute path the entry was deleted from
+	DeletedAt time.Time // Local deletion time
+	IsDir     bool
+}
+
+// HomeTrash returns the user's trash: $XDG_DATA_HOME/Trash, or ~/.local/share/Trash
+func HomeTrash() (Trash, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Trash{}, err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return Trash{Dir: filepath.Join(dataHome, "Trash")}, nil
+}
+
+// Put moves path into the trash and returns the new item. The .trashinfo file
+// is created first so a unique name is reserved even when trashing concurrently.
+// Entries on other filesystems are copied into the trash and then removed.
+func (t Trash) Put(path string) (TrashItem, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	for _, dir := range []string{t.filesDir(), t.infoDir()} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return TrashItem{}, err
+		}
+	}
+
+	item := TrashItem{Original: abs, DeletedAt: time.Now(), IsDir: info.IsDir()}
+	infoFile, err := t.reserve(&item)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	_, err = fmt.Fprintf(infoFile, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escapeTrashPath(abs), item.DeletedAt.Format(trashTimeFormat))
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = Move(abs, t.filePath(item))
+	}
+	if err != nil {
+		os.Remove(t.infoPath(item))
+		return TrashItem{}, err
+	}
+	return item, nil
+}
+
+// List returns the items in the trash, most recently deleted first.
+// Info files that cannot be parsed or have no matching entry are skipped.
+func (t Trash) List() ([]TrashItem, error) {
+	entries, err := os.ReadDir(t.infoDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TrashItem
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !ok {
+			continue
+		}
+		item, err := t.readInfo(name)
+		if err != nil {
+			continue
+		}
+		info, err := os.Lstat(t.filePath(item))
+		if err != nil {
+			continue
+		}
+		item.IsDir = info.IsDir()
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// Restore moves an item back to where it was deleted from, recreating missing
+// parent directories. It fails when something else now exists at that path.
+func (t Trash) Restore(item TrashItem) error {
+	if err := os.MkdirAll(filepath.Dir(item.Original), 0755); err != nil {
+		return err
+	}
+	if err := Move(t.filePath(item), item.Original); err != nil {
+		return err
+	}
+	return os.Remove(t.infoPath(item))
+}
+
+// Purge permanently deletes an item from the trash
+func (t Trash) Purge(item TrashItem) error {
+	if err := os.RemoveAll(t.filePath(item)); err != nil {
+		return err
+	}
+	return os.Remove(t.infoPath(item))
+}
+
+// reserve picks a free name for item by creating its info file exclusively
+func (t Trash) reserve(item *TrashItem) (*os.File, error) {
+	base := filepath.Base(item.Original)
+	ext := filepath.Ext(base)
+	if ext == base {
+		ext = "" // Dotfiles such as .bashrc have no extension
+	}
+	stem := strings.TrimSuffix(base, ext)
+
+	for i := 1; ; i++ {
+		item.Name = base
+		if i > 1 {
+			item.Name = stem + "." + strconv.Itoa(i) + ext
+		}
+		if _, err := os.Lstat(t.filePath(*item)); err == nil {
+			continue // A stray entry without info file
+		}
+		file, err := os.OpenFile(t.infoPath(*item), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, err
+	}
+}
+
+// readInfo parses the .trashinfo file of the entry called name
+func (t Trash) readInfo(name string) (TrashItem, error) {
+	file, err := os.Open(t.infoPath(TrashItem{Name: name}))
+	if err != nil {
+		return TrashItem{}, err
+	}
+	defer file.Close()
+
+	item := TrashItem{Name: name}
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "[Trash Info]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inSection || !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			path, err := url.PathUnescape(value)
+			if err != nil {
+				return TrashItem{}, err
+			}
+			item.Original = filepath.FromSlash(path)
+		case "DeletionDate":
+			// A missing or malformed date is tolerated; the item just sorts last
+			item.DeletedAt, _ = time.ParseInLocation(trashTimeFormat, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return TrashItem{}, err
+	}
+	if item.Original == "" {
+		return TrashItem{}, fmt.Errorf("%s: no Path entry", t.infoPath(item))
+	}
+	return item, nil
+}
+
+func (t Trash) filesDir() string { return filepath.Join(t.Dir, "files") }
+
+func (t Trash) infoDir() string { return filepath.Join(t.Dir, "info") }
+
+func (t Trash) filePath(item TrashItem) string { return filepath.Join(t.filesDir(), item.Name) }
+
+func (t Trash) infoPath(item TrashItem) string {
+	return filepath.Join(t.infoDir(), item.Name+".trashinfo")
+}
+
+// escapeTrashPath percent-encodes a path for the Path key, keeping the slashes
+func escapeTrashPath(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
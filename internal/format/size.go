@@ -0,0 +1,23 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Size renders a byte count compactly in binary units, like `ls -lh`
+func Size(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return strconv.FormatInt(size, 10)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 5 {
+		value /= unit
+		exp++
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", value, "KMGTPE"[exp])
+	}
+	return fmt.Sprintf("%.0f%c", value, "KMGTPE"[exp])
+}
@@ -0,0 +1,233 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// State is the git status of a single path
+type State int
+
+const (
+	Clean State = iota
+	Modified
+	Staged
+	Untracked
+	Ignored
+	Conflicted
+	Renamed
+)
+
+// Symbol returns the short marker shown next to a path in the tree
+func (s State) Symbol() string {
+	switch s {
+	case Modified:
+		return "M"
+	case Staged:
+		return "A"
+	case Untracked:
+		return "?"
+	case Ignored:
+		return "!"
+	case Conflicted:
+		return "U"
+	case Renamed:
+		return "R"
+	default:
+		return ""
+	}
+}
+
+// IsChange reports whether the state should be surfaced as a change for review
+func (s State) IsChange() bool {
+	return s != Clean && s != Ignored
+}
+
+// Status is a snapshot of the index and working tree of one repository
+type Status struct {
+	treePath string           // Path the snapshot was loaded for, as used by tree nodes
+	prefix   string           // treePath relative to the repository root (slash separated)
+	files    map[string]State // Repository-relative path -> state
+	dirty    map[string]bool  // Repository-relative directories containing changes
+}
+
+// InWorkTree reports whether path looks like it is inside a git work tree,
+// by looking for a .git entry in it or a parent without running git
+func InWorkTree(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+// Load reads the git status of the repository containing path using the local
+// git binary. It returns an error when path is not inside a work tree.
+func Load(path string) (*Status, error) {
+	top, err := run(path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	prefix, err := filepath.Rel(top, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := run(path, "status", "--porcelain=v1", "-z", "--ignored", "--untracked-files=normal")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Status{
+		treePath: path,
+		prefix:   filepath.ToSlash(prefix),
+		files:    make(map[string]State),
+		dirty:    make(map[string]bool),
+	}
+	s.parse(out)
+	return s, nil
+}
+
+// parse consumes `git status --porcelain=v1 -z` output
+func (s *Status) parse(out string) {
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y := entry[0], entry[1]
+		path := strings.TrimSuffix(entry[3:], "/")
+
+		state := classify(x, y)
+		if x == 'R' || y == 'R' || x == 'C' || y == 'C' {
+			// The original path of a rename or copy follows as its own entry
+			i++
+		}
+		s.files[path] = state
+
+		if state.IsChange() {
+			for dir := parentDir(path); dir != ""; dir = parentDir(dir) {
+				s.dirty[dir] = true
+			}
+			s.dirty["."] = true
+		}
+	}
+}
+
+// classify maps a porcelain XY code to a single state, most significant first
+func classify(x, y byte) State {
+	switch {
+	case x == '?' && y == '?':
+		return Untracked
+	case x == '!' && y == '!':
+		return Ignored
+	case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+		return Conflicted
+	case x == 'R' || y == 'R':
+		return Renamed
+	case y != ' ':
+		return Modified
+	default:
+		return Staged
+	}
+}
+
+// Of returns the state of a tree path. Paths inside untracked or ignored
+// directories inherit the directory's state.
+func (s *Status) Of(path string) State {
+	key, ok := s.key(path)
+	if !ok {
+		return Clean
+	}
+	if state, ok := s.files[key]; ok {
+		return state
+	}
+	for dir := parentDir(key); dir != ""; dir = parentDir(dir) {
+		if state := s.files[dir]; state == Untracked || state == Ignored {
+			return state
+		}
+	}
+	return Clean
+}
+
+// ContainsChanges reports whether any path below dir has a reviewable change
+func (s *Status) ContainsChanges(dir string) bool {
+	key, ok := s.key(dir)
+	return ok && s.dirty[key]
+}
+
+// Changed lists the tree paths of every reviewable change below the tree root
+func (s *Status) Changed() []string {
+	var paths []string
+	for key, state := range s.files {
+		if !state.IsChange() {
+			continue
+		}
+		rel := key
+		if s.prefix != "." {
+			if !strings.HasPrefix(key, s.prefix+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(key, s.prefix+"/")
+		}
+		paths = append(paths, filepath.Join(s.treePath, filepath.FromSlash(rel)))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// key converts a tree path into a repository-relative key
+func (s *Status) key(path string) (string, bool) {
+	rel, err := filepath.Rel(s.treePath, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	key := filepath.ToSlash(filepath.Join(filepath.FromSlash(s.prefix), rel))
+	return key, true
+}
+
+// parentDir returns the parent of a slash separated relative path, or "" at the top
+func parentDir(path string) string {
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// run executes git in dir and returns its standard output
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,542 @@
+package preview
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TokenKind classifies a span of highlighted source
+type TokenKind int
+
+const (
+	TokenPlain TokenKind = iota
+	TokenKeyword
+	TokenType
+	TokenString
+	TokenNumber
+	TokenComment
+	TokenKey      // YAML and JSON keys
+	TokenVariable // Shell variables
+	TokenHeading  // Markdown headings
+	TokenCode     // Markdown code spans and fences
+	TokenEmphasis // Markdown bold and italics
+	TokenLink     // Markdown links
+)
+
+// Token is a run of text sharing one kind
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// languageByExt maps file extensions to highlighter languages
+var languageByExt = map[string]string{
+	".go":       "go",
+	".ts":       "typescript",
+	".tsx":      "typescript",
+	".mts":      "typescript",
+	".js":       "javascript",
+	".jsx":      "javascript",
+	".mjs":      "javascript",
+	".cjs":      "javascript",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".json":     "json",
+	".sh":       "shell",
+	".bash":     "shell",
+	".zsh":      "shell",
+	".py":       "python",
+}
+
+// shebangLanguages maps interpreter names found in a "#!" line to languages
+var shebangLanguages = []struct {
+	interpreter string
+	language    string
+}{
+	{"python", "python"},
+	{"node", "javascript"},
+	{"deno", "typescript"},
+	{"bash", "shell"},
+	{"zsh", "shell"},
+	{"sh", "shell"},
+}
+
+// DetectLanguage picks a highlighter from the file extension, falling back to the shebang line
+func DetectLanguage(path, firstLine string) string {
+	if language, ok := languageByExt[strings.ToLower(filepath.Ext(path))]; ok {
+		return language
+	}
+	if !strings.HasPrefix(firstLine, "#!") {
+		return ""
+	}
+
+	// "#!/usr/bin/env python3" and "#!/bin/sh -e" both name the interpreter in the first two fields
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	for i, field := range fields {
+		if i > 1 {
+			break
+		}
+		name := filepath.Base(field)
+		for _, candidate := range shebangLanguages {
+			if strings.HasPrefix(name, candidate.interpreter) {
+				return candidate.language
+			}
+		}
+	}
+	return ""
+}
+
+// Highlighter tokenizes a file line by line, carrying state such as open
+// block comments, multi-line strings and Markdown fences between lines
+type Highlighter struct {
+	lexer lexer
+}
+
+// lexer is implemented by each language family
+type lexer interface {
+	line(text string) []Token
+}
+
+// NewHighlighter returns a highlighter for language, or nil when it is unsupported
+func NewHighlighter(language string) *Highlighter {
+	var l lexer
+	switch language {
+	case "go":
+		l = &codeLexer{syntax: goSyntax}
+	case "typescript", "javascript":
+		l = &codeLexer{syntax: tsSyntax}
+	case "json":
+		l = &codeLexer{syntax: jsonSyntax}
+	case "shell":
+		l = &codeLexer{syntax: shellSyntax}
+	case "python":
+		l = &codeLexer{syntax: pythonSyntax}
+	case "yaml":
+		l = &yamlLexer{}
+	case "markdown":
+		l = &markdownLexer{}
+	default:
+		return nil
+	}
+	return &Highlighter{lexer: l}
+}
+
+// Line tokenizes the next line of the file
+func (h *Highlighter) Line(text string) []Token {
+	return h.lexer.line(text)
+}
+
+// tokenWriter accumulates tokens, merging adjacent runs of the same kind
+type tokenWriter struct {
+	tokens []Token
+}
+
+func (w *tokenWriter) emit(kind TokenKind, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(w.tokens); n > 0 && w.tokens[n-1].Kind == kind {
+		w.tokens[n-1].Text += text
+		return
+	}
+	w.tokens = append(w.tokens, Token{Kind: kind, Text: text})
+}
+
+// codeSyntax describes a C-like or hash-comment language
+type codeSyntax struct {
+	lineComments   []string
+	blockStart     string
+	blockEnd       string
+	quotes         string // Characters that open single-line strings
+	multilineQuote byte   // Quote that may span lines (0 for none)
+	rawMultiline   bool   // The multi-line quote does not process escapes
+	keywords       map[string]bool
+	types          map[string]bool
+	variables      bool // Highlight $NAME and ${NAME}
+	keyStrings     bool // Strings followed by ':' are keys
+}
+
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
+
+var goSyntax = &codeSyntax{
+	lineComments:   []string{"//"},
+	blockStart:     "/*",
+	blockEnd:       "*/",
+	quotes:         `"'`,
+	multilineQuote: '`',
+	rawMultiline:   true,
+	keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var nil true false iota`),
+	types: words(`bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune
+		string uint uint8 uint16 uint32 uint64 uintptr any comparable`),
+}
+
+var tsSyntax = &codeSyntax{
+	lineComments:   []string{"//"},
+	blockStart:     "/*",
+	blockEnd:       "*/",
+	quotes:         `"'`,
+	multilineQuote: '`',
+	keywords: words(`abstract as async await break case catch class const continue debugger declare default
+		delete do else enum export extends false finally for from function get if implements import in
+		instanceof interface keyof let new null of private protected public readonly return set static
+		super switch this throw true try type typeof undefined var void while yield`),
+	types: words(`any boolean never number object string symbol unknown bigint Array Promise Record Map Set`),
+}
+
+var jsonSyntax = &codeSyntax{
+	quotes:     `"`,
+	keywords:   words(`true false null`),
+	keyStrings: true,
+}
+
+var shellSyntax = &codeSyntax{
+	lineComments: []string{"#"},
+	quotes:       `"'`,
+	keywords: words(`if then else elif fi for while until do done case esac in function return local
+		export readonly set unset shift exit break continue source`),
+	variables: true,
+}
+
+var pythonSyntax = &codeSyntax{
+	lineComments: []string{"#"},
+	quotes:       `"'`,
+	keywords: words(`and as assert async await break class continue def del elif else except False
+		finally for from global if import in is lambda None nonlocal not or pass raise return True try
+		while with yield`),
+	types: words(`bool bytes dict float int list object set str tuple`),
+}
+
+// codeLexer tokenizes languages described by a codeSyntax
+type codeLexer struct {
+	syntax         *codeSyntax
+	inBlockComment bool
+	inString       bool // Inside a multi-line string
+}
+
+func (l *codeLexer) line(text string) []Token {
+	s := l.syntax
+	w := &tokenWriter{}
+	i := 0
+
+	if l.inBlockComment {
+		end := strings.Index(text, s.blockEnd)
+		if end < 0 {
+			w.emit(TokenComment, text)
+			return w.tokens
+		}
+		i = end + len(s.blockEnd)
+		w.emit(TokenComment, text[:i])
+		l.inBlockComment = false
+	}
+	if l.inString {
+		end, closed := scanString(text, 0, s.multilineQuote, !s.rawMultiline)
+		w.emit(TokenString, text[:end])
+		if !closed {
+			return w.tokens
+		}
+		i = end
+		l.inString = false
+	}
+
+	for i < len(text) {
+		rest := text[i:]
+		c := text[i]
+
+		if hasAnyPrefix(rest, s.lineComments) && (i == 0 || s.blockStart != "" || text[i-1] == ' ' || text[i-1] == '\t') {
+			w.emit(TokenComment, rest)
+			break
+		}
+		if s.blockStart != "" && strings.HasPrefix(rest, s.blockStart) {
+			end := strings.Index(rest[len(s.blockStart):], s.blockEnd)
+			if end < 0 {
+				w.emit(TokenComment, rest)
+				l.inBlockComment = true
+				break
+			}
+			end += len(s.blockStart) + len(s.blockEnd)
+			w.emit(TokenComment, rest[:end])
+			i += end
+			continue
+		}
+		if strings.IndexByte(s.quotes, c) >= 0 || (s.multilineQuote != 0 && c == s.multilineQuote) {
+			multiline := s.multilineQuote != 0 && c == s.multilineQuote
+			escapes := !(multiline && s.rawMultiline)
+			end, closed := scanString(text, i+1, c, escapes)
+			kind := TokenString
+			if s.keyStrings && strings.HasPrefix(strings.TrimLeft(text[end:], " \t"), ":") {
+				kind = TokenKey
+			}
+			w.emit(kind, text[i:end])
+			if !closed && multiline {
+				l.inString = true
+			}
+			i = end
+			continue
+		}
+		if s.variables && c == '$' && i+1 < len(text) {
+			end := scanVariable(text, i)
+			if end > i+1 {
+				w.emit(TokenVariable, text[i:end])
+				i = end
+				continue
+			}
+		}
+		if isDigit(c) {
+			end := i
+			for end < len(text) && (isIdentChar(text[end]) || text[end] == '.') {
+				end++
+			}
+			w.emit(TokenNumber, text[i:end])
+			i = end
+			continue
+		}
+		if isIdentStart(c) {
+			end := i
+			for end < len(text) && isIdentChar(text[end]) {
+				end++
+			}
+			word := text[i:end]
+			switch {
+			case s.keywords[word]:
+				w.emit(TokenKeyword, word)
+			case s.types[word]:
+				w.emit(TokenType, word)
+			default:
+				w.emit(TokenPlain, word)
+			}
+			i = end
+			continue
+		}
+
+		w.emit(TokenPlain, text[i:i+1])
+		i++
+	}
+	return w.tokens
+}
+
+// scanString returns the index just past the closing quote starting the search
+// at start, and whether the string was closed on this line
+func scanString(text string, start int, quote byte, escapes bool) (int, bool) {
+	for i := start; i < len(text); i++ {
+		if escapes && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == quote {
+			return i + 1, true
+		}
+	}
+	return len(text), false
+}
+
+// scanVariable returns the end of a $NAME, ${...} or $1 reference starting at i
+func scanVariable(text string, i int) int {
+	if text[i+1] == '{' {
+		if end := strings.IndexByte(text[i:], '}'); end >= 0 {
+			return i + end + 1
+		}
+		return len(text)
+	}
+	end := i + 1
+	for end < len(text) && isIdentChar(text[end]) {
+		end++
+	}
+	return end
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isIdentChar(c byte) bool  { return isIdentStart(c) || isDigit(c) }
+
+// yamlKey matches an optional list marker followed by a mapping key
+var yamlKey = regexp.MustCompile(`^(\s*(?:-\s+)*)("[^"]*"|'[^']*'|[^\s#'"\-][^:#]*?|-[^\s:#][^:#]*?)(\s*:)(\s|$)`)
+
+// yamlScalar matches values that get their own color
+var yamlScalar = regexp.MustCompile(`^(true|false|yes|no|on|off|null|~|True|False|Null|NULL)$`)
+
+// yamlLexer highlights YAML keys, scalars and comments
+type yamlLexer struct{}
+
+func (l *yamlLexer) line(text string) []Token {
+	w := &tokenWriter{}
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "---" || trimmed == "..." {
+		w.emit(TokenKeyword, text)
+		return w.tokens
+	}
+
+	rest := text
+	if m := yamlKey.FindStringSubmatch(text); m != nil {
+		indent, key, colon := m[1], m[2], m[3]
+		writeListMarkers(w, indent)
+		w.emit(TokenKey, key)
+		w.emit(TokenPlain, colon)
+		rest = text[len(indent)+len(key)+len(colon):]
+	} else {
+		indent := text[:len(text)-len(strings.TrimLeft(text, " \t-"))]
+		writeListMarkers(w, indent)
+		rest = text[len(indent):]
+	}
+
+	l.value(w, rest)
+	return w.tokens
+}
+
+// writeListMarkers emits indentation with "-" list markers highlighted
+func writeListMarkers(w *tokenWriter, indent string) {
+	for _, r := range indent {
+		if r == '-' {
+			w.emit(TokenKeyword, "-")
+		} else {
+			w.emit(TokenPlain, string(r))
+		}
+	}
+}
+
+// value highlights the scalar part of a line, including a trailing comment
+func (l *yamlLexer) value(w *tokenWriter, text string) {
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	w.emit(TokenPlain, leading)
+	body := text[len(leading):]
+
+	switch {
+	case body == "":
+		return
+	case body[0] == '#':
+		w.emit(TokenComment, body)
+		return
+	case body[0] == '"' || body[0] == '\'':
+		end, _ := scanString(body, 1, body[0], body[0] == '"')
+		w.emit(TokenString, body[:end])
+		l.value(w, body[end:])
+		return
+	}
+
+	// Plain scalars run until a " #" comment
+	comment := strings.Index(body, " #")
+	value, trailing := body, ""
+	if comment >= 0 {
+		value, trailing = body[:comment], body[comment:]
+	}
+
+	trimmedValue := strings.TrimRight(value, " \t")
+	switch {
+	case yamlScalar.MatchString(trimmedValue), trimmedValue == "|", trimmedValue == ">",
+		strings.HasPrefix(trimmedValue, "|") && len(trimmedValue) <= 3,
+		strings.HasPrefix(trimmedValue, ">") && len(trimmedValue) <= 3:
+		w.emit(TokenKeyword, value)
+	case isNumber(trimmedValue):
+		w.emit(TokenNumber, value)
+	case strings.HasPrefix(trimmedValue, "&"), strings.HasPrefix(trimmedValue, "*"), strings.HasPrefix(trimmedValue, "!"):
+		w.emit(TokenType, value)
+	default:
+		w.emit(TokenPlain, value)
+	}
+	if trailing != "" {
+		w.emit(TokenPlain, " ")
+		w.emit(TokenComment, trailing[1:])
+	}
+}
+
+// isNumber reports whether s looks like an integer or decimal literal
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	dot := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case isDigit(s[i]):
+		case s[i] == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return s != "" && s != "."
+}
+
+// markdownInline matches code spans, bold/italic emphasis and links
+var markdownInline = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*|\\[[^\\]]+\\]\\([^)]*\\)")
+
+// markdownList matches bullet and numbered list markers
+var markdownList = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])(\s+)`)
+
+// markdownLexer highlights headings, fences, lists and inline spans
+type markdownLexer struct {
+	fence string // Opening fence while inside a code block
+}
+
+func (l *markdownLexer) line(text string) []Token {
+	w := &tokenWriter{}
+	trimmed := strings.TrimLeft(text, " ")
+
+	if l.fence != "" {
+		w.emit(TokenCode, text)
+		if strings.HasPrefix(trimmed, l.fence) {
+			l.fence = ""
+		}
+		return w.tokens
+	}
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, fence) {
+			l.fence = fence
+			w.emit(TokenCode, text)
+			return w.tokens
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		w.emit(TokenHeading, text)
+		return w.tokens
+	case strings.HasPrefix(trimmed, ">"):
+		w.emit(TokenComment, text)
+		return w.tokens
+	}
+
+	rest := text
+	if m := markdownList.FindStringSubmatch(text); m != nil {
+		w.emit(TokenPlain, m[1])
+		w.emit(TokenKeyword, m[2])
+		w.emit(TokenPlain, m[3])
+		rest = text[len(m[0]):]
+	}
+
+	last := 0
+	for _, span := range markdownInline.FindAllStringIndex(rest, -1) {
+		w.emit(TokenPlain, rest[last:span[0]])
+		match := rest[span[0]:span[1]]
+		switch match[0] {
+		case '`':
+			w.emit(TokenCode, match)
+		case '[':
+			w.emit(TokenLink, match)
+		default:
+			w.emit(TokenEmphasis, match)
+		}
+		last = span[1]
+	}
+	w.emit(TokenPlain, rest[last:])
+	return w.tokens
+}
@@ -0,0 +1,215 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"dtree/internal/format"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTextBytes bounds how much of a file is read for a text preview
+const maxTextBytes = 256 * 1024
+
+// sniffBytes is how much of a file is inspected to tell text from binary
+const sniffBytes = 8 * 1024
+
+// Kind classifies what a preview shows
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindDirectory
+	KindBinary
+	KindSpecial // Devices, pipes and sockets, which are described but not read
+	KindError
+)
+
+// Preview is the rendered-independent content shown beside the tree
+type Preview struct {
+	Path      string
+	Kind      Kind
+	Info      string   // One-line metadata summary
+	Lines     []string // Text lines, directory entries or hex dump rows
+	Truncated bool     // More content exists than was loaded
+	Language  string   // Highlighter language for text previews, if recognized
+	Err       error
+}
+
+// Load builds a preview of path holding at most maxLines lines of content
+func Load(path string, maxLines int) *Preview {
+	p := &Preview{Path: path}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		p.Kind = KindError
+		p.Err = err
+		return p
+	}
+	p.Info = describe(info)
+
+	if info.IsDir() {
+		p.Kind = KindDirectory
+		p.Lines, p.Truncated, p.Err = listDirectory(path, maxLines)
+		return p
+	}
+
+	// Opening a FIFO blocks until a writer shows up, and devices never end
+	if !info.Mode().IsRegular() {
+		p.Kind = KindSpecial
+		return p
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		p.Kind = KindError
+		p.Err = err
+		return p
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(sniffBytes)
+	if isBinary(head) {
+		p.Kind = KindBinary
+		p.Lines, p.Truncated = hexDump(reader, maxLines)
+		return p
+	}
+
+	p.Kind = KindText
+	p.Lines, p.Truncated = readLines(reader, maxLines)
+	firstLine := ""
+	if len(p.Lines) > 0 {
+		firstLine = p.Lines[0]
+	}
+	p.Language = DetectLanguage(path, firstLine)
+	return p
+}
+
+// describe formats the metadata line shown for every preview
+func describe(info os.FileInfo) string {
+	return fmt.Sprintf("%s  %s  %s", info.Mode(), format.Size(info.Size()), info.ModTime().Format("2006-01-02 15:04"))
+}
+
+// isBinary treats data with NUL bytes or invalid UTF-8 as binary
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	if utf8.Valid(head) {
+		return false
+	}
+	// A multi-byte rune may be cut off at the end of the sniffed block
+	for cut := 1; cut < utf8.UTFMax && cut < len(head); cut++ {
+		if utf8.Valid(head[:len(head)-cut]) {
+			return false
+		}
+	}
+	return true
+}
+
+// readLines reads up to maxLines lines, expanding tabs and making control characters visible
+func readLines(r io.Reader, maxLines int) ([]string, bool) {
+	scanner := bufio.NewScanner(io.LimitReader(r, maxTextBytes))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTextBytes)
+
+	var lines []string
+	for scanner.Scan() {
+		if len(lines) == maxLines {
+			return lines, true
+		}
+		lines = append(lines, sanitize(strings.ReplaceAll(scanner.Text(), "\t", "    ")))
+	}
+	return lines, scanner.Err() != nil
+}
+
+// sanitize replaces control characters with visible stand-ins so escape
+// sequences in a file or name cannot reach the terminal: C0 characters and DEL
+// become their Control Pictures symbol (ESC shows as ␛), C1 characters become �
+func sanitize(s string) string {
+	clean := func(r rune) bool { return r >= 0x20 && r != 0x7f && (r < 0x80 || r > 0x9f) }
+	if strings.IndexFunc(s, func(r rune) bool { return !clean(r) }) < 0 {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case clean(r):
+			return r
+		case r < 0x20:
+			return 0x2400 + r
+		case r == 0x7f:
+			return 0x2421
+		default:
+			return utf8.RuneError
+		}
+	}, s)
+}
+
+// hexDump renders the start of a binary file like `hexdump -C`
+func hexDump(r io.Reader, maxLines int) ([]string, bool) {
+	var lines []string
+	row := make([]byte, 16)
+	for offset := 0; len(lines) < maxLines; offset += len(row) {
+		n, err := io.ReadFull(r, row)
+		if n == 0 {
+			return lines, false
+		}
+		lines = append(lines, hexRow(offset, row[:n]))
+		if err != nil {
+			return lines, false
+		}
+	}
+	_, err := r.Read(make([]byte, 1))
+	return lines, err == nil
+}
+
+// hexRow formats one 16-byte row: offset, hex bytes and printable ASCII
+func hexRow(offset int, data []byte) string {
+	var hexPart, asciiPart strings.Builder
+	for i := 0; i < 16; i++ {
+		if i == 8 {
+			hexPart.WriteByte(' ')
+		}
+		if i < len(data) {
+			fmt.Fprintf(&hexPart, "%02x ", data[i])
+			if data[i] >= 0x20 && data[i] < 0x7f {
+				asciiPart.WriteByte(data[i])
+			} else {
+				asciiPart.WriteByte('.')
+			}
+		} else {
+			hexPart.WriteString("   ")
+		}
+	}
+	return fmt.Sprintf("%08x  %s |%s|", offset, hexPart.String(), asciiPart.String())
+}
+
+// listDirectory lists entry names, directories first and marked with a slash
+func listDirectory(path string, maxLines int) ([]string, bool, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	var lines []string
+	for _, entry := range entries {
+		if len(lines) == maxLines {
+			return lines, true, nil
+		}
+		name := sanitize(entry.Name())
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		lines = append(lines, name)
+	}
+	return lines, false, nil
+}
@@ -0,0 +1,26 @@
+package tree
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+)
+
+// DiskUsage sums the apparent size of everything below path without following
+// symlinks. Unreadable entries are skipped; cancelling ctx stops the walk early.
+func DiskUsage(ctx context.Context, path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,75 @@
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Insert adds the existing entry name of n's directory as a child, keeping the
+// sort order, and returns it. Nothing is added when n has not been loaded yet
+// (expanding it will list the entry) or when the tree options filter the entry
+// out; Insert then returns nil. An entry that is already a child is returned as is.
+func (n *Node) Insert(name string) *Node {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	if !n.IsExpanded && len(n.Children) == 0 {
+		return nil
+	}
+
+	info, err := os.Lstat(filepath.Join(n.Path, name))
+	if err != nil {
+		return nil
+	}
+	children := n.newChildren([]fs.DirEntry{fs.FileInfoToDirEntry(info)}, n.Options(), n.Root().Path, n.Ignored)
+	if len(children) == 0 {
+		return nil
+	}
+	n.AddChildren(children)
+	return children[0]
+}
+
+// Detach removes n from its parent's children
+func (n *Node) Detach() {
+	if n.Parent == nil {
+		return
+	}
+	siblings := n.Parent.Children
+	for i, sibling := range siblings {
+		if sibling == n {
+			n.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+			return
+		}
+	}
+}
+
+// Move updates the tree after n's file was moved into parent's directory as
+// name. Loaded children and expansion state travel with the node. It returns
+// the node at the new location, or nil when it is not shown there (see Insert).
+func (n *Node) Move(parent *Node, name string) *Node {
+	n.Detach()
+	moved := parent.Insert(name)
+	if moved == nil || !moved.IsDir || !n.IsDir {
+		return moved
+	}
+
+	moved.IsExpanded, moved.Err = n.IsExpanded, n.Err
+	moved.Children = n.Children
+	for _, child := range moved.Children {
+		child.reparent(moved)
+	}
+	return moved
+}
+
+// reparent fixes the parent, path and depth of n and its loaded descendants
+func (n *Node) reparent(parent *Node) {
+	n.Parent = parent
+	n.Path = filepath.Join(parent.Path, n.Name)
+	n.Depth = parent.Depth + 1
+	for _, child := range n.Children {
+		child.reparent(n)
+	}
+}
@@ -0,0 +1,12 @@
+package tree
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isHidden applies the dotfile policy: names starting with a dot are hidden on
+// every platform, and on Windows so are entries carrying the hidden attribute
+func isHidden(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".") || hasHiddenAttribute(path)
+}
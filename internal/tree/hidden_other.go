@@ -0,0 +1,8 @@
+//go:build !windows
+
+package tree
+
+// hasHiddenAttribute is always false outside Windows, where only dotfiles are hidden
+func hasHiddenAttribute(path string) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package tree
+
+import "syscall"
+
+// hasHiddenAttribute reports whether Explorer would hide the entry
+func hasHiddenAttribute(path string) bool {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	return err == nil && attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
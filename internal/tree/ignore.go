@@ -0,0 +1,344 @@
+package tree
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DtreeIgnoreFile holds dtree-specific patterns using the .gitignore syntax
+const DtreeIgnoreFile = ".dtreeignore"
+
+// ignorePattern is a single compiled line of an ignore file
+type ignorePattern struct {
+	base    string // Directory the pattern is relative to
+	re      *regexp.Regexp
+	negate  bool // Pattern started with '!'
+	dirOnly bool // Pattern ended with '/'
+}
+
+// ignoreMatcher resolves gitignore-style rules for paths inside one tree.
+// Ignore files are parsed on first use and cached per directory; the cache
+// is locked so directories can be loaded in parallel.
+type ignoreMatcher struct {
+	mu       sync.Mutex
+	top      string                     // Tree root, used as the anchor outside git repositories
+	dirs     map[string][]ignorePattern // .gitignore and .dtreeignore patterns per directory
+	repos    map[string]string          // Directory -> enclosing repository root ("" if none)
+	excludes map[string][]ignorePattern // Repository root -> global and info/exclude patterns
+}
+
+func newIgnoreMatcher(top string) *ignoreMatcher {
+	return &ignoreMatcher{
+		top:      top,
+		dirs:     make(map[string][]ignorePattern),
+		repos:    make(map[string]string),
+		excludes: make(map[string][]ignorePattern),
+	}
+}
+
+// match reports whether path is excluded; like git, the last matching pattern wins
+func (m *ignoreMatcher) match(path string, isDir bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Dir(path)
+	anchor := m.repoRoot(dir)
+
+	var patterns []ignorePattern
+	if anchor != "" {
+		patterns = append(patterns, m.repoExcludes(anchor)...)
+	} else {
+		anchor = m.top
+	}
+	for _, d := range dirChain(anchor, dir) {
+		patterns = append(patterns, m.dirPatterns(d)...)
+	}
+
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].matches(path, isDir) {
+			return !patterns[i].negate
+		}
+	}
+	return false
+}
+
+// dirPatterns returns the patterns declared by ignore files directly inside dir
+func (m *ignoreMatcher) dirPatterns(dir string) []ignorePattern {
+	if patterns, ok := m.dirs[dir]; ok {
+		return patterns
+	}
+
+	patterns := readIgnoreFile(filepath.Join(dir, ".gitignore"), dir)
+	patterns = append(patterns, readIgnoreFile(filepath.Join(dir, DtreeIgnoreFile), dir)...)
+	m.dirs[dir] = patterns
+	return patterns
+}
+
+// forget drops the cached ignore files of dir so they are read again
+func (m *ignoreMatcher) forget(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.dirs, dir)
+}
+
+// repoRoot finds the closest ancestor of dir containing a .git entry
+func (m *ignoreMatcher) repoRoot(dir string) string {
+	if root, ok := m.repos[dir]; ok {
+		return root
+	}
+
+	root := ""
+	if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = m.repoRoot(parent)
+	}
+	m.repos[dir] = root
+	return root
+}
+
+// repoExcludes loads the global excludes file and .git/info/exclude for a repository
+func (m *ignoreMatcher) repoExcludes(root string) []ignorePattern {
+	if patterns, ok := m.excludes[root]; ok {
+		return patterns
+	}
+
+	var patterns []ignorePattern
+	if global := globalExcludesFile(); global != "" {
+		patterns = append(patterns, readIgnoreFile(global, root)...)
+	}
+	if gitDir := resolveGitDir(root); gitDir != "" {
+		patterns = append(patterns, readIgnoreFile(filepath.Join(gitDir, "info", "exclude"), root)...)
+	}
+	m.excludes[root] = patterns
+	return patterns
+}
+
+// matches tests the pattern against path, which may be a descendant of a matched directory
+func (p ignorePattern) matches(path string, isDir bool) bool {
+	rel, err := filepath.Rel(p.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	loc := p.re.FindStringSubmatchIndex(filepath.ToSlash(rel))
+	if loc == nil {
+		return false
+	}
+	// A trailing-slash pattern only matches directories; a descendant match implies one
+	matchedDescendant := loc[2] >= 0
+	return !p.dirOnly || isDir || matchedDescendant
+}
+
+// readIgnoreFile parses an ignore file whose patterns are relative to base
+func readIgnoreFile(path, base string) []ignorePattern {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if pattern, ok := compileIgnorePattern(scanner.Text(), base); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// compileIgnorePattern converts one gitignore line into a matcher
+func compileIgnorePattern(line, base string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	pattern := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// Patterns containing a slash are anchored to the ignore file's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	expr.WriteString(globToRegexp(line))
+	expr.WriteString("(/.*)?$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.re = re
+	return pattern, true
+}
+
+// globToRegexp translates gitignore glob syntax (*, ?, [...], **) into a regexp body
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// dirChain lists directories from anchor down to dir (inclusive), or just dir
+// when it does not live under anchor
+func dirChain(anchor, dir string) []string {
+	rel, err := filepath.Rel(anchor, dir)
+	if anchor == "" || err != nil || strings.HasPrefix(rel, "..") {
+		return []string{dir}
+	}
+
+	chain := []string{anchor}
+	if rel == "." {
+		return chain
+	}
+	current := anchor
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		chain = append(chain, current)
+	}
+	return chain
+}
+
+// resolveGitDir returns the git directory of a repository, following "gitdir:" files
+func resolveGitDir(root string) string {
+	gitPath := filepath.Join(root, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return gitPath
+	}
+
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir
+}
+
+// globalExcludesFile locates git's core.excludesFile, falling back to the XDG default
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	var configs []string
+	if configHome != "" {
+		configs = append(configs, filepath.Join(configHome, "git", "config"))
+	}
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+
+	// Later config files take precedence, matching git's own lookup order
+	excludes := ""
+	for _, config := range configs {
+		if value := readCoreExcludesFile(config); value != "" {
+			excludes = value
+		}
+	}
+
+	if excludes == "" {
+		if configHome == "" {
+			return ""
+		}
+		return filepath.Join(configHome, "git", "ignore")
+	}
+	if strings.HasPrefix(excludes, "~/") && home != "" {
+		excludes = filepath.Join(home, excludes[2:])
+	}
+	return excludes
+}
+
+// readCoreExcludesFile extracts core.excludesFile from a git config file
+func readCoreExcludesFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inCore := false
+	value := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inCore = strings.EqualFold(strings.Trim(line, "[] \t"), "core")
+			continue
+		}
+		if !inCore {
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			value = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return value
+}
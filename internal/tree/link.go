@@ -0,0 +1,38 @@
+package tree
+
+import (
+	"os"
+)
+
+// Link describes the target of a symbolic link node
+type Link struct {
+	Target string // Target as stored in the link, possibly relative
+	Broken bool   // The target does not exist
+	Loop   bool   // The target is a directory above the link, so it is not followed
+}
+
+// readLink inspects the symlink at path below n and reports whether it should
+// be treated as a directory. Directory links are only followed when follow is
+// set, and never when the target is n or one of its ancestors.
+func (n *Node) readLink(path string, follow bool) (*Link, bool) {
+	target, _ := os.Readlink(path)
+	link := &Link{Target: target}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		link.Broken = true
+		return link, false
+	}
+	if !follow || !info.IsDir() {
+		return link, false
+	}
+
+	// os.SameFile compares device and inode, so loops are found whatever path reached them
+	for ancestor := n; ancestor != nil; ancestor = ancestor.Parent {
+		if ancestorInfo, err := os.Stat(ancestor.Path); err == nil && os.SameFile(info, ancestorInfo) {
+			link.Loop = true
+			break
+		}
+	}
+	return link, true
+}
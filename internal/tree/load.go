@@ -0,0 +1,87 @@
+package tree
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// maxParallelLoads bounds how many directories Build reads at the same time
+const maxParallelLoads = 8
+
+// streamBatchSize is how many directory entries StreamChildren reads per batch
+const streamBatchSize = 256
+
+// ChildBatch is one part of a directory listing streamed by StreamChildren
+type ChildBatch struct {
+	Children []*Node
+	Err      error // Why the listing stopped early; only set on the last batch
+}
+
+// StreamChildren lists the directory in the background and sends its child
+// nodes in batches as they are read, so huge or slow directories can be shown
+// while loading. The nodes are not attached to n; pass each batch to
+// AddChildren. The channel is closed when the listing ends or ctx is cancelled.
+func (n *Node) StreamChildren(ctx context.Context) <-chan ChildBatch {
+	batches := make(chan ChildBatch)
+	opts := n.Options().clone() // Snapshot the settings before leaving the caller's goroutine
+	top, ignored, loop := n.Root().Path, n.Ignored, n.Link != nil && n.Link.Loop
+	go func() {
+		defer close(batches)
+		if loop {
+			return
+		}
+		send := func(batch ChildBatch) bool {
+			select {
+			case batches <- batch:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		dir, err := os.Open(n.Path)
+		if err != nil {
+			send(ChildBatch{Err: err})
+			return
+		}
+		defer dir.Close()
+
+		for ctx.Err() == nil {
+			entries, err := dir.ReadDir(streamBatchSize)
+			batch := ChildBatch{Children: n.newChildren(entries, opts, top, ignored)}
+			if err != nil && err != io.EOF {
+				batch.Err = err
+			}
+			if (len(batch.Children) > 0 || batch.Err != nil) && !send(batch) {
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return batches
+}
+
+// AddChildren attaches nodes streamed by StreamChildren, merging them into the
+// existing children so the tree's sort order is kept
+func (n *Node) AddChildren(children []*Node) {
+	opts := n.Options()
+	sortNodes(children, opts)
+
+	merged := make([]*Node, 0, len(n.Children)+len(children))
+	i, j := 0, 0
+	for i < len(n.Children) && j < len(children) {
+		if opts.less(children[j], n.Children[i]) {
+			merged = append(merged, children[j])
+			j++
+		} else {
+			merged = append(merged, n.Children[i])
+			i++
+		}
+	}
+	merged = append(merged, n.Children[i:]...)
+	merged = append(merged, children[j:]...)
+	n.Children = merged
+}
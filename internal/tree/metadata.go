@@ -0,0 +1,39 @@
+package tree
+
+import (
+	"os"
+	"time"
+)
+
+// Metadata holds the os.FileInfo-derived details recorded for each node
+type Metadata struct {
+	Size    int64
+	Mode    os.FileMode
+	ModTime time.Time
+	Owner   string // User name, or the numeric id when it cannot be resolved
+	Group   string // Group name, or the numeric id when it cannot be resolved
+	Inode   uint64
+	Links   uint64
+}
+
+// newMetadata extracts metadata from info, filling platform-specific fields where available
+func newMetadata(info os.FileInfo) Metadata {
+	meta := Metadata{
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		Links:   1,
+	}
+	fillSysMetadata(&meta, info)
+	return meta
+}
+
+// Stat refreshes the node's metadata from the file system without following symlinks
+func (n *Node) Stat() error {
+	info, err := os.Lstat(n.Path)
+	if err != nil {
+		return err
+	}
+	n.Meta = newMetadata(info)
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tree
+
+import "os"
+
+// fillSysMetadata is a no-op where ownership and inodes are not exposed through os.FileInfo
+func fillSysMetadata(meta *Metadata, info os.FileInfo) {}
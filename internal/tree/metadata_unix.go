@@ -0,0 +1,58 @@
+//go:build unix
+
+package tree
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// Name lookups hit /etc/passwd or NSS, so resolved ids are cached for the process
+var (
+	idNamesMu  sync.Mutex
+	userNames  = map[uint32]string{}
+	groupNames = map[uint32]string{}
+)
+
+// fillSysMetadata copies ownership, inode and link count from the stat structure
+func fillSysMetadata(meta *Metadata, info os.FileInfo) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	meta.Inode = uint64(st.Ino)
+	meta.Links = uint64(st.Nlink)
+	meta.Owner = lookupID(userNames, st.Uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+	meta.Group = lookupID(groupNames, st.Gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+// lookupID resolves a numeric id through the cache, falling back to the number itself
+func lookupID(cache map[uint32]string, id uint32, lookup func(string) (string, error)) string {
+	idNamesMu.Lock()
+	defer idNamesMu.Unlock()
+
+	if name, ok := cache[id]; ok {
+		return name
+	}
+	name, err := lookup(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		name = strconv.FormatUint(uint64(id), 10)
+	}
+	cache[id] = name
+	return name
+}
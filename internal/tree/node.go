@@ -1,8 +1,20 @@
 package tree
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tree drawing characters shared by the TUI and the print mode
+const (
+	BranchPrefix = "├── "
+	LastPrefix   = "└── "
+	PipeIndent   = "│   "
+	BlankIndent  = "    "
 )
 
 // Node represents a file or directory in the tree structure
@@ -11,43 +23,247 @@ type Node struct {
 	Path       string
 	IsDir      bool
 	IsExpanded bool
+	Ignored    bool     // Matched by an ignore file (only loaded when ignored entries are shown)
+	Meta       Metadata // Size, mode, ownership and times as of the last load
+	Link       *Link    // Symlink target, nil for regular entries
+	Err        error    // Why the directory could not be read during the last load, if it failed
 	Children   []*Node
 	Parent     *Node
 	Depth      int
+
+	opts *Options // Shared by every node of the same tree
 }
 
 // Build creates the initial tree structure with specified depth expansion
 func Build(rootPath string, initialDepth int) *Node {
+	return BuildWithOptions(rootPath, initialDepth, &Options{})
+}
+
+// BuildWithOptions creates the initial tree structure using the given loading options
+func BuildWithOptions(rootPath string, initialDepth int, opts *Options) *Node {
 	root := &Node{
 		Name:       filepath.Base(rootPath),
 		Path:       rootPath,
 		IsDir:      true,
 		IsExpanded: true,
 		Depth:      0,
+		opts:       opts,
 	}
+	root.Stat()
 
 	loadChildrenRecursive(root, initialDepth)
 	return root
 }
 
+// Options returns the loading options shared by the tree this node belongs to
+func (n *Node) Options() *Options {
+	if n.opts == nil {
+		n.opts = &Options{}
+	}
+	return n.opts
+}
+
 // LoadChildren reads directory contents and creates child nodes
 func (n *Node) LoadChildren() {
+	n.Children = append(n.Children, n.readChildren()...)
+}
+
+// Reload re-reads this directory and every loaded directory below it.
+// Children that still exist keep their node, so expansion state survives.
+func (n *Node) Reload() {
+	n.Options().resetIgnores()
+	n.Stat()
+	n.reload()
+}
+
+// Refresh re-reads only this directory's own entries. Entries that still exist
+// keep their node and loaded children; nothing below is read again.
+func (n *Node) Refresh() {
+	if n.opts != nil && n.opts.ignores != nil {
+		n.opts.ignores.forget(n.Path) // Its ignore files may be among the changes
+	}
+	n.Stat()
+	n.merge(n.readChildren())
+}
+
+// reload diffs fresh directory entries against the existing children
+func (n *Node) reload() {
+	for _, child := range n.merge(n.readChildren()) {
+		if child.IsDir && (child.IsExpanded || len(child.Children) > 0) {
+			child.reload()
+		}
+	}
+}
+
+// merge makes fresh the children, reusing the existing node for every entry
+// that is still there, and returns the reused nodes
+func (n *Node) merge(fresh []*Node) []*Node {
+	existing := make(map[string]*Node, len(n.Children))
+	for _, child := range n.Children {
+		existing[child.Name] = child
+	}
+
+	var kept []*Node
+	for i, child := range fresh {
+		old, ok := existing[child.Name]
+		if !ok || old.IsDir != child.IsDir {
+			continue
+		}
+		old.Ignored = child.Ignored
+		old.Meta = child.Meta
+		old.Link = child.Link
+		fresh[i] = old
+		kept = append(kept, old)
+	}
+	n.Children = fresh
+	return kept
+}
+
+// readChildren lists the directory and creates child nodes, applying the tree options
+func (n *Node) readChildren() []*Node {
+	if n.Link != nil && n.Link.Loop {
+		return nil
+	}
+
+	// A failed read may still return the entries listed before the error
 	entries, err := os.ReadDir(n.Path)
-	if err != nil {
-		return
+	n.Err = err
+
+	opts := n.Options()
+	children := n.newChildren(entries, opts, n.Root().Path, n.Ignored)
+	if opts.Sort != (SortOrder{}) {
+		sortNodes(children, opts) // os.ReadDir already returns entries in name order
 	}
+	return children
+}
 
+// newChildren creates nodes for the entries of n that opts lets through. It only
+// reads fields of n that never change, so it is safe to call from a loader goroutine.
+func (n *Node) newChildren(entries []fs.DirEntry, opts *Options, top string, ignoredParent bool) []*Node {
+	var children []*Node
 	for _, entry := range entries {
 		childPath := filepath.Join(n.Path, entry.Name())
+		isDir := entry.IsDir()
+		var link *Link
+		if entry.Type()&os.ModeSymlink != 0 {
+			link, isDir = n.readLink(childPath, opts.FollowLinks)
+		}
+
+		if (opts.HideHidden && isHidden(childPath)) || opts.excludes(top, childPath, isDir) {
+			continue
+		}
+		ignored := ignoredParent || opts.isIgnored(top, childPath, isDir)
+		if ignored && opts.HideIgnored {
+			continue
+		}
+
 		child := &Node{
-			Name:   entry.Name(),
-			Path:   childPath,
-			IsDir:  entry.IsDir(),
-			Parent: n,
-			Depth:  n.Depth + 1,
+			Name:    entry.Name(),
+			Path:    childPath,
+			IsDir:   isDir,
+			Link:    link,
+			Ignored: ignored,
+			Parent:  n,
+			Depth:   n.Depth + 1,
+			opts:    n.opts,
+		}
+		if info, err := entry.Info(); err == nil {
+			child.Meta = newMetadata(info)
 		}
-		n.Children = append(n.Children, child)
+		children = append(children, child)
 	}
+	return children
+}
+
+// ErrorSummary describes the load error briefly, e.g. "permission denied", or returns ""
+func (n *Node) ErrorSummary() string {
+	if n.Err == nil {
+		return ""
+	}
+	var pathErr *fs.PathError
+	if errors.As(n.Err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return n.Err.Error()
+}
+
+// ExpandTo loads and expands every directory between n and path, returning
+// the node for path or nil when it is not part of the tree
+func (n *Node) ExpandTo(path string) *Node {
+	return n.ExpandToSkipping(path, nil)
+}
+
+// ExpandToSkipping is ExpandTo that does not read directories for which busy
+// reports true, such as ones still streaming in, and only looks among the
+// children they have so far
+func (n *Node) ExpandToSkipping(path string, busy func(*Node) bool) *Node {
+	rel, err := filepath.Rel(n.Path, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	if rel == "." {
+		return n
+	}
+
+	current := n
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if !current.IsDir {
+			return nil
+		}
+		if len(current.Children) == 0 && (busy == nil || !busy(current)) {
+			current.LoadChildren()
+		}
+		current.IsExpanded = true
+
+		var next *Node
+		for _, child := range current.Children {
+			if child.Name == part {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// Find returns the already loaded node for path, without loading or expanding anything
+func (n *Node) Find(path string) *Node {
+	rel, err := filepath.Rel(n.Path, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	if rel == "." {
+		return n
+	}
+
+	current := n
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		var next *Node
+		for _, child := range current.Children {
+			if child.Name == part {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// Root returns the top node of the tree this node belongs to
+func (n *Node) Root() *Node {
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return root
 }
 
 // IsLastChild determines if a node is the last child of its parent
@@ -65,31 +281,32 @@ func (n *Node) IsLastChild() bool {
 	return visibleSiblings[len(visibleSiblings)-1] == n
 }
 
-// loadChildrenRecursive loads directory contents up to the initial depth
-func loadChildrenRecursive(node *Node, initialDepth int) {
-	if node.Depth >= initialDepth {
-		return
-	}
-
-	entries, err := os.ReadDir(node.Path)
-	if err != nil {
+// loadChildrenRecursive loads directory contents up to the initial depth,
+// reading up to maxParallelLoads directories at once
+func loadChildrenRecursive(root *Node, initialDepth int) {
+	if root.Depth >= initialDepth {
 		return
 	}
+	root.Options().matcher(root.Root().Path) // Create the shared ignore cache before the goroutines start
 
-	for _, entry := range entries {
-		childPath := filepath.Join(node.Path, entry.Name())
-		child := &Node{
-			Name:   entry.Name(),
-			Path:   childPath,
-			IsDir:  entry.IsDir(),
-			Parent: node,
-			Depth:  node.Depth + 1,
-		}
-		node.Children = append(node.Children, child)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxParallelLoads)
+	var load func(node *Node)
+	load = func(node *Node) {
+		defer wg.Done()
+		slots <- struct{}{}
+		node.Children = node.readChildren()
+		<-slots
 
-		if child.IsDir && child.Depth < initialDepth {
-			child.IsExpanded = true
-			loadChildrenRecursive(child, initialDepth)
+		for _, child := range node.Children {
+			if child.IsDir && child.Depth < initialDepth {
+				child.IsExpanded = true
+				wg.Add(1)
+				go load(child)
+			}
 		}
 	}
+	wg.Add(1)
+	load(root)
+	wg.Wait()
 }
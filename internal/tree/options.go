@@ -0,0 +1,59 @@
+package tree
+
+import "path/filepath"
+
+// Options controls which directory entries are loaded into the tree
+type Options struct {
+	HideIgnored bool       // Skip entries matched by .gitignore, .dtreeignore and git excludes
+	HideHidden  bool       // Skip dotfiles (and Windows hidden entries)
+	Include     PatternSet // Like tree -P: only files matching these are loaded; directories are kept
+	Exclude     PatternSet // Like tree -I: entries matching these are not loaded
+	Sort        SortOrder  // Order of children within each directory
+	FollowLinks bool       // Expand symlinks to directories, except ones that loop back to an ancestor
+
+	// DirSize supplies aggregate directory sizes for SortSize, e.g. from a
+	// disk-usage scan. Without it directories sort by their own entry size.
+	DirSize func(*Node) (int64, bool)
+
+	ignores *ignoreMatcher // Lazily parsed ignore files
+}
+
+// clone copies the settings without sharing any cached state
+func (o *Options) clone() *Options {
+	if o == nil {
+		return &Options{}
+	}
+	c := *o
+	c.ignores = nil
+	return &c
+}
+
+// isIgnored reports whether the ignore files exclude path in the tree rooted at top
+func (o *Options) isIgnored(top, path string, isDir bool) bool {
+	return o.matcher(top).match(path, isDir)
+}
+
+// matcher returns the ignore cache, creating it for the tree rooted at top on first use
+func (o *Options) matcher(top string) *ignoreMatcher {
+	if o.ignores == nil {
+		o.ignores = newIgnoreMatcher(top)
+	}
+	return o.ignores
+}
+
+// excludes reports whether the Include and Exclude patterns filter out path
+func (o *Options) excludes(top, path string, isDir bool) bool {
+	if len(o.Include) == 0 && len(o.Exclude) == 0 {
+		return false
+	}
+	name, rel := filepath.Base(path), relativeSlashPath(top, path)
+	if o.Exclude.Match(name, rel) {
+		return true
+	}
+	return !isDir && len(o.Include) > 0 && !o.Include.Match(name, rel)
+}
+
+// resetIgnores drops cached ignore files so they are re-read on the next load
+func (o *Options) resetIgnores() {
+	o.ignores = nil
+}
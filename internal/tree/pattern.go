@@ -0,0 +1,123 @@
+package tree
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches entries by name, or by path relative to the root when it
+// contains a slash
+type Pattern struct {
+	Source string
+	negate bool
+	path   bool
+	re     *regexp.Regexp
+}
+
+// ParsePattern compiles a filter pattern. Globs are the default ("*.proto")
+// with "|" separating alternatives as in `tree -P`; "re:" or /.../ selects an
+// unanchored regular expression; a leading "!" negates the pattern.
+func ParsePattern(s string) (Pattern, error) {
+	p := Pattern{Source: s}
+	body := s
+	if strings.HasPrefix(body, "!") {
+		p.negate = true
+		body = body[1:]
+	}
+	if body == "" {
+		return p, fmt.Errorf("empty pattern %q", s)
+	}
+
+	var expr string
+	switch {
+	case strings.HasPrefix(body, "re:"):
+		expr = body[len("re:"):]
+		p.path = strings.Contains(expr, "/")
+	case len(body) > 2 && strings.HasPrefix(body, "/") && strings.HasSuffix(body, "/"):
+		expr = body[1 : len(body)-1]
+		p.path = strings.Contains(expr, "/")
+	default:
+		var alternatives []string
+		for _, glob := range strings.Split(body, "|") {
+			if strings.Contains(glob, "/") {
+				p.path = true
+			}
+			alternatives = append(alternatives, globToRegexp(strings.TrimPrefix(glob, "/")))
+		}
+		expr = "^(?:" + strings.Join(alternatives, "|") + ")$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return p, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// matches reports whether the pattern matches, ignoring negation
+func (p Pattern) matches(name, rel string) bool {
+	if p.path {
+		return p.re.MatchString(rel)
+	}
+	return p.re.MatchString(name)
+}
+
+// PatternSet is an ordered list of patterns where the last matching pattern
+// wins, as in .gitignore. A set made only of negated patterns matches
+// everything they do not, so "!*_test.go" means "everything except tests".
+type PatternSet []Pattern
+
+// ParsePatterns compiles every pattern in list
+func ParsePatterns(list []string) (PatternSet, error) {
+	set := make(PatternSet, 0, len(list))
+	for _, s := range list {
+		p, err := ParsePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Match reports whether an entry with the given name and slash-separated
+// path relative to the root is selected by the set. An empty set matches nothing.
+func (s PatternSet) Match(name, rel string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	matched := true
+	for _, p := range s {
+		if !p.negate {
+			matched = false
+			break
+		}
+	}
+	for _, p := range s {
+		if p.matches(name, rel) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+// String joins the pattern sources with spaces
+func (s PatternSet) String() string {
+	sources := make([]string, len(s))
+	for i, p := range s {
+		sources[i] = p.Source
+	}
+	return strings.Join(sources, " ")
+}
+
+// relativeSlashPath returns path relative to top using forward slashes
+func relativeSlashPath(top, path string) string {
+	rel, err := filepath.Rel(top, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
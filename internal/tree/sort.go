@@ -0,0 +1,190 @@
+package tree
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortKey selects what children are ordered by
+type SortKey int
+
+const (
+	SortName            SortKey = iota // Byte order, as returned by os.ReadDir
+	SortNatural                        // Version order: file2 before file10
+	SortCaseInsensitive                // Name ignoring case
+	SortSize                           // Largest first
+	SortTime                           // Most recently modified first
+	SortExtension                      // Extension, then name
+)
+
+// sortKeyNames are the names used on the command line and in status messages
+var sortKeyNames = []string{"name", "version", "iname", "size", "mtime", "extension"}
+
+// String returns the command-line name of the key
+func (k SortKey) String() string {
+	if k < 0 || int(k) >= len(sortKeyNames) {
+		return fmt.Sprintf("SortKey(%d)", int(k))
+	}
+	return sortKeyNames[k]
+}
+
+// ParseSortKey converts a command-line sort name
+func ParseSortKey(name string) (SortKey, error) {
+	switch strings.ToLower(name) {
+	case "natural":
+		return SortNatural, nil
+	case "ext":
+		return SortExtension, nil
+	case "time":
+		return SortTime, nil
+	}
+	for i, keyName := range sortKeyNames {
+		if strings.EqualFold(name, keyName) {
+			return SortKey(i), nil
+		}
+	}
+	return SortName, fmt.Errorf("unknown sort %q (want %s)", name, strings.Join(sortKeyNames, ", "))
+}
+
+// SortOrder describes how the children of every directory are ordered
+type SortOrder struct {
+	Key       SortKey
+	DirsFirst bool // Directories before files, regardless of Reverse
+	Reverse   bool
+}
+
+// String describes the order for status messages
+func (o SortOrder) String() string {
+	s := o.Key.String()
+	if o.Reverse {
+		s += ", reversed"
+	}
+	if o.DirsFirst {
+		s += ", directories first"
+	}
+	return s
+}
+
+// Sort re-orders the children of n and every loaded directory below it using the tree's sort order
+func (n *Node) Sort() {
+	n.sortChildren()
+	for _, child := range n.Children {
+		child.Sort()
+	}
+}
+
+// sortChildren orders the direct children of n
+func (n *Node) sortChildren() {
+	sortNodes(n.Children, n.Options())
+}
+
+// sortNodes orders siblings by the sort order in opts
+func sortNodes(nodes []*Node, opts *Options) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return opts.less(nodes[i], nodes[j])
+	})
+}
+
+// less reports whether a sorts before b among siblings
+func (o *Options) less(a, b *Node) bool {
+	order := o.Sort
+	if order.DirsFirst && a.IsDir != b.IsDir {
+		return a.IsDir
+	}
+	c := compareNodes(a, b, order.Key, o.DirSize)
+	if order.Reverse {
+		return c > 0
+	}
+	return c < 0
+}
+
+// compareNodes returns a negative number when a sorts before b
+func compareNodes(a, b *Node, key SortKey, dirSize func(*Node) (int64, bool)) int {
+	switch key {
+	case SortNatural:
+		if c := compareNatural(a.Name, b.Name); c != 0 {
+			return c
+		}
+	case SortCaseInsensitive:
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+	case SortSize:
+		if c := compareInt(nodeSize(b, dirSize), nodeSize(a, dirSize)); c != 0 {
+			return c
+		}
+	case SortTime:
+		if c := b.Meta.ModTime.Compare(a.Meta.ModTime); c != 0 {
+			return c
+		}
+	case SortExtension:
+		extA, extB := strings.ToLower(filepath.Ext(a.Name)), strings.ToLower(filepath.Ext(b.Name))
+		if c := strings.Compare(extA, extB); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// nodeSize is the size used for sorting; directories without a known size sort as -1
+func nodeSize(n *Node, dirSize func(*Node) (int64, bool)) int64 {
+	if !n.IsDir {
+		return n.Meta.Size
+	}
+	if dirSize == nil {
+		return n.Meta.Size
+	}
+	if size, ok := dirSize(n); ok {
+		return size
+	}
+	return -1
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareNatural compares names case-insensitively, treating runs of digits as numbers
+func compareNatural(a, b string) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		chunkA, restA := splitChunk(a)
+		chunkB, restB := splitChunk(b)
+		if isDigit(chunkA[0]) && isDigit(chunkB[0]) {
+			// Compare numbers by magnitude without parsing, so long runs cannot overflow
+			numA, numB := strings.TrimLeft(chunkA, "0"), strings.TrimLeft(chunkB, "0")
+			if c := compareInt(int64(len(numA)), int64(len(numB))); c != 0 {
+				return c
+			}
+			if c := strings.Compare(numA, numB); c != 0 {
+				return c
+			}
+		} else if c := strings.Compare(chunkA, chunkB); c != 0 {
+			return c
+		}
+		a, b = restA, restB
+	}
+	return compareInt(int64(len(a)), int64(len(b)))
+}
+
+// splitChunk splits off the leading run of digits or non-digits
+func splitChunk(s string) (chunk, rest string) {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
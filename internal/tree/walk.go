@@ -0,0 +1,36 @@
+package tree
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// Walk visits every entry below rootPath that the options would load, in
+// lexical order. Unreadable directories are skipped; returning an error from
+// fn stops the walk (filepath.SkipDir skips the current directory).
+// Symlinked directories are listed but never descended into, so loops cannot occur.
+// Walk keeps its own ignore cache so it is safe to run in the background.
+func Walk(rootPath string, opts *Options, fn func(path string, isDir bool) error) error {
+	walkOpts := opts.clone()
+	return filepath.WalkDir(rootPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == rootPath {
+				return err
+			}
+			return nil
+		}
+		if path == rootPath {
+			return nil
+		}
+
+		hidden := walkOpts.HideHidden && isHidden(path)
+		if hidden || walkOpts.excludes(rootPath, path, entry.IsDir()) ||
+			(walkOpts.HideIgnored && walkOpts.isIgnored(rootPath, path, entry.IsDir())) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, entry.IsDir())
+	})
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"dtree/internal/columns"
+	"dtree/internal/tree"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// minNameWidth is the room kept for the tree and names before columns are dropped
+const minNameWidth = 24
+
+// dropOrder lists columns from least to most important when the pane is too narrow
+var dropOrder = []columns.Column{columns.Inode, columns.Links, columns.Group, columns.Owner, columns.Permissions, columns.Time, columns.Size}
+
+// SetColumns chooses the metadata columns shown beside names. A non-empty
+// set also becomes what the L key toggles back to.
+func (m *Model) SetColumns(set columns.Set) {
+	m.columns = set
+	if set != 0 {
+		m.longColumns = set
+	}
+}
+
+// toggleColumns shows or hides the metadata columns
+func (m *Model) toggleColumns() {
+	if m.columns != 0 {
+		m.columns = 0
+		return
+	}
+	m.columns = m.longColumns
+	if m.columns == 0 {
+		m.columns = columns.Long
+	}
+}
+
+// addColumns appends aligned metadata and disk-usage columns to the rendered
+// tree lines, dropping the least important columns when the tree pane is too narrow
+func (m *Model) addColumns(lines []string, nodes []*tree.Node) []string {
+	if (m.columns == 0 && !m.du.enabled) || len(lines) == 0 {
+		return lines
+	}
+
+	var duCells []string
+	reserved := 0
+	if m.du.enabled {
+		duCells = m.duCells(nodes)
+		reserved = lipgloss.Width(duCells[0]) + 1
+	}
+	metaCells := m.metadataCells(nodes, m.treePaneWidth()-minNameWidth-reserved)
+
+	suffixes := make([]string, len(lines))
+	for i := range suffixes {
+		var parts []string
+		if metaCells != nil {
+			parts = append(parts, metaCells[i])
+		}
+		if duCells != nil {
+			parts = append(parts, duCells[i])
+		}
+		suffixes[i] = strings.Join(parts, " ")
+	}
+
+	nameWidth := max(m.treePaneWidth()-lipgloss.Width(suffixes[0])-1, 1)
+	clip := lipgloss.NewStyle().MaxWidth(nameWidth)
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		left := clip.Render(line)
+		left += strings.Repeat(" ", max(nameWidth-lipgloss.Width(left), 0))
+		result[i] = left + " " + m.ignoredStyle.Render(suffixes[i])
+	}
+	return result
+}
+
+// metadataCells joins the enabled metadata columns for each node, dropping
+// columns until they fit in budget. It returns nil when no column fits.
+func (m *Model) metadataCells(nodes []*tree.Node, budget int) []string {
+	set, next := m.columns, 0
+	for set != 0 {
+		formatter := columns.Formatter{Columns: set.List(), Now: time.Now(), Human: true, Relative: true}
+		rows := make([][]string, len(nodes))
+		for i, node := range nodes {
+			rows[i] = formatter.Cells(node.Meta)
+		}
+		widths := columns.Widths(rows)
+		if columnsWidth(widths) <= budget {
+			cells := make([]string, len(rows))
+			for i, row := range rows {
+				cells[i] = formatter.Join(row, widths)
+			}
+			return cells
+		}
+
+		for next < len(dropOrder) && !set.Has(dropOrder[next]) {
+			next++
+		}
+		if next == len(dropOrder) {
+			break
+		}
+		set = set.Without(dropOrder[next])
+	}
+	return nil
+}
+
+// columnsWidth is the total width of the joined columns
+func columnsWidth(widths []int) int {
+	total := max(len(widths)-1, 0)
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"context"
+	"dtree/internal/format"
+	"dtree/internal/tree"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// duBarWidth is the number of cells in the percentage bar
+const duBarWidth = 10
+
+// duState tracks disk-usage mode: measured directory totals and the running measurement
+type duState struct {
+	enabled bool
+	sort    tree.SortOrder   // Order to restore when the mode is turned off
+	sizes   map[string]int64 // Aggregate size of every directory measured so far
+	scope   string           // Directory whose children are being measured
+	results <-chan duResult  // nil when no measurement is running
+	cancel  context.CancelFunc
+}
+
+// duResult is the aggregate size of one directory
+type duResult struct {
+	path string
+	size int64
+}
+
+// duMsg delivers a measured directory; done means the measurement finished
+type duMsg struct {
+	results <-chan duResult
+	result  duResult
+	done    bool
+}
+
+// SetDiskUsage turns disk-usage mode on or off, re-sorting loaded directories
+func (m *Model) SetDiskUsage(enabled bool) {
+	if enabled == m.du.enabled {
+		return
+	}
+	m.du.enabled = enabled
+
+	opts := m.tree.Options()
+	if enabled {
+		if m.du.sizes == nil {
+			m.du.sizes = make(map[string]int64)
+		}
+		m.du.sort = opts.Sort
+		opts.DirSize = m.diskUsage
+		m.applySort(tree.SortOrder{Key: tree.SortSize})
+	} else {
+		m.stopDiskUsage()
+		opts.DirSize = nil
+		m.applySort(m.du.sort)
+	}
+}
+
+// toggleDiskUsage switches disk-usage mode from the keyboard
+func (m *Model) toggleDiskUsage() {
+	m.SetDiskUsage(!m.du.enabled)
+	if m.du.enabled {
+		m.setInfo("Disk usage mode: sizes are measured in the background")
+	} else {
+		m.setInfo("Disk usage mode off")
+	}
+}
+
+// stopDiskUsage cancels the running measurement, if any
+func (m *Model) stopDiskUsage() {
+	if m.du.cancel != nil {
+		m.du.cancel()
+	}
+	m.du.results, m.du.cancel, m.du.scope = nil, nil, ""
+}
+
+// duScope is the directory whose children should be measured: the expanded
+// directory under the cursor, or otherwise the cursor's parent
+func (m *Model) duScope() *tree.Node {
+	node := m.currentNode()
+	if node == nil {
+		return nil
+	}
+	if (node.IsDir && node.IsExpanded) || node.Parent == nil {
+		return node
+	}
+	return node.Parent
+}
+
+// duCmd starts measuring the current scope, cancelling the previous
+// measurement when the user has navigated somewhere else
+func (m *Model) duCmd() tea.Cmd {
+	if !m.du.enabled {
+		return nil
+	}
+	scope := m.duScope()
+	if scope == nil || scope.Path == m.du.scope {
+		return nil
+	}
+
+	m.stopDiskUsage()
+	m.du.scope = scope.Path
+	if m.measured(scope) {
+		return nil
+	}
+
+	known := make(map[string]int64)
+	for path, size := range m.du.sizes {
+		if filepath.Dir(path) == scope.Path {
+			known[path] = size
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.du.cancel = cancel
+	m.du.results = measureChildren(ctx, scope.Path, known)
+	return waitForDiskUsage(m.du.results)
+}
+
+// measured reports whether dir and all of its loaded subdirectories have totals
+func (m *Model) measured(dir *tree.Node) bool {
+	if _, ok := m.du.sizes[dir.Path]; !ok {
+		return false
+	}
+	for _, child := range dir.Children {
+		if _, ok := m.du.sizes[child.Path]; child.IsDir && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// measureChildren measures each subdirectory of dir on disk, then dir itself.
+// Directories in known are not walked again.
+func measureChildren(ctx context.Context, dir string, known map[string]int64) <-chan duResult {
+	results := make(chan duResult)
+	go func() {
+		defer close(results)
+		send := func(r duResult) bool {
+			select {
+			case results <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		var total int64
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() {
+				if info, err := entry.Info(); err == nil {
+					total += info.Size()
+				}
+				continue
+			}
+
+			size, ok := known[path]
+			if !ok {
+				size, _ = tree.DiskUsage(ctx, path)
+				if ctx.Err() != nil || !send(duResult{path: path, size: size}) {
+					return
+				}
+			}
+			total += size
+		}
+		send(duResult{path: dir, size: total})
+	}()
+	return results
+}
+
+// waitForDiskUsage receives the next measured directory
+func waitForDiskUsage(results <-chan duResult) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-results
+		return duMsg{results: results, result: result, done: !ok}
+	}
+}
+
+// handleDiskUsage records a measured size and re-sorts its siblings
+func (m *Model) handleDiskUsage(msg duMsg) tea.Cmd {
+	if msg.results != m.du.results {
+		return nil // Stale result from a cancelled measurement
+	}
+	if msg.done {
+		m.du.results, m.du.cancel = nil, nil
+		return nil
+	}
+
+	m.du.sizes[msg.result.path] = msg.result.size
+	if parent := m.tree.Find(filepath.Dir(msg.result.path)); parent != nil {
+		current := m.currentNode()
+		parent.Sort()
+		m.updateFlattenedNodes()
+		m.restoreCursor(current)
+	}
+	return waitForDiskUsage(msg.results)
+}
+
+// diskUsage returns the size of a file or the measured total of a directory
+func (m *Model) diskUsage(node *tree.Node) (int64, bool) {
+	if !node.IsDir {
+		return node.Meta.Size, true
+	}
+	size, ok := m.du.sizes[node.Path]
+	return size, ok
+}
+
+// duCells renders the size, percentage bar and percentage of each node, padded to a common width
+func (m *Model) duCells(nodes []*tree.Node) []string {
+	cells := make([]string, len(nodes))
+	sizeWidth := 0
+	sizes := make([]string, len(nodes))
+	for i, node := range nodes {
+		size, ok := m.diskUsage(node)
+		switch {
+		case ok:
+			sizes[i] = format.Size(size)
+		case m.du.results != nil && node.Parent != nil && node.Parent.Path == m.du.scope:
+			sizes[i] = "…"
+		}
+		sizeWidth = max(sizeWidth, len([]rune(sizes[i])))
+	}
+
+	for i, node := range nodes {
+		cell := strings.Repeat(" ", sizeWidth-len([]rune(sizes[i]))) + sizes[i]
+		size, ok := m.diskUsage(node)
+		var total int64
+		var totalOK bool
+		if node.Parent != nil {
+			total, totalOK = m.du.sizes[node.Parent.Path]
+		}
+		if ok && totalOK && total > 0 {
+			fraction := min(float64(size)/float64(total), 1)
+			filled := int(fraction*duBarWidth + 0.5)
+			cell += fmt.Sprintf(" %s%s %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", duBarWidth-filled), fraction*100)
+		} else {
+			cell += strings.Repeat(" ", duBarWidth+6)
+		}
+		cells[i] = cell
+	}
+	return cells
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// execMsg reports that a program given the terminal for path has exited
+type execMsg struct {
+	path   string
+	action string // "edit" or "open"
+	err    error
+}
+
+// splitLineSuffix separates a trailing ":42" line number from a query, returning 0 without one
+func splitLineSuffix(query string) (string, int) {
+	i := strings.LastIndexByte(query, ':')
+	if i < 0 {
+		return query, 0
+	}
+	line, err := strconv.Atoi(query[i+1:])
+	if err != nil || line <= 0 {
+		return query, 0
+	}
+	return query[:i], line
+}
+
+// editCurrent opens the file under the cursor in the editor, at the line
+// from the search query when the file is one of its matches
+func (m *Model) editCurrent() tea.Cmd {
+	node := m.currentNode()
+	if node == nil {
+		return nil
+	}
+	return m.edit(node.Path, m.searchLine(node))
+}
+
+// searchLine returns the line number of a "file:42" search when node is one of its matches
+func (m *Model) searchLine(node *tree.Node) int {
+	if _, line := splitLineSuffix(m.search.query); line > 0 && m.matchesSearch(node) {
+		return line
+	}
+	return 0
+}
+
+// edit suspends the TUI and runs the editor in the foreground on path
+func (m *Model) edit(path string, line int) tea.Cmd {
+	if node := m.tree.Find(path); node != nil && node.IsDir {
+		m.SetStatus(fmt.Sprintf("%s is a directory", node.Name))
+		return nil
+	}
+	cmd := fileops.EditorCommand(path, line)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return execMsg{path: path, action: "edit", err: err}
+	})
+}
+
+// handleExec refreshes the file's directory once the program exits, since
+// saving may have replaced the file or left backups behind
+func (m *Model) handleExec(msg execMsg) {
+	if parent := m.tree.Find(filepath.Dir(msg.path)); parent != nil {
+		m.reloadNode(parent)
+	}
+	switch {
+	case msg.err != nil && msg.action == "edit":
+		m.SetStatus(fmt.Sprintf("Editor failed: %v", msg.err))
+	case msg.err != nil:
+		m.SetStatus(fileops.FormatOpenError(msg.path, msg.err))
+	case msg.action == "edit":
+		m.setInfo(fmt.Sprintf("Edited %s", m.displayPath(msg.path)))
+	default:
+		m.status = ""
+	}
+}
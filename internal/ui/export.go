@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"dtree/internal/export"
+	"dtree/internal/fileops"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultExportName is suggested when exporting from the TUI
+const defaultExportName = "dtree-export.json"
+
+// startExport asks where to write the currently expanded view
+func (m *Model) startExport() {
+	p := &prompt{label: "Export to (.txt/.json/.yaml/.xml): ", value: []rune(defaultExportName)}
+	p.onSubmit = func(value string) tea.Cmd {
+		if value != "" {
+			m.exportView(value)
+		}
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// exportView writes the expanded, filtered view; relative paths are resolved
+// against the tree root, and an existing file is only replaced after asking
+func (m *Model) exportView(path string) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.tree.Path, path)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		m.confirmOverwrite(path, func() { m.exportView(path) })
+		return
+	}
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Error exporting: %v", err))
+		return
+	}
+	opts := export.Options{Format: export.FormatForPath(path), Report: true, Include: m.isVisible}
+	err = export.Write(file, m.tree, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Error exporting: %v", err))
+		return
+	}
+
+	// The export may have been written inside the tree
+	m.reload()
+	m.setInfo(fmt.Sprintf("Exported view to %s", path))
+	m.record(fileops.Operation{Kind: fileops.OpCreate, Path: path})
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startCreate asks for the name of a new file, or directory when it ends with
+// a slash, inside the directory under the cursor or next to the file
+func (m *Model) startCreate() {
+	node := m.currentNode()
+	if node == nil {
+		return
+	}
+	dir := node
+	if !node.IsDir && node.Parent != nil {
+		dir = node.Parent
+	}
+
+	p := &prompt{label: fmt.Sprintf("New file in %s (end with / for a directory): ", m.displayPath(dir.Path))}
+	p.onSubmit = func(value string) tea.Cmd {
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+		path := filepath.Join(dir.Path, value)
+		create := fileops.CreateFile
+		isDir := strings.HasSuffix(value, "/") || strings.HasSuffix(value, string(filepath.Separator))
+		if isDir {
+			create = fileops.CreateDir
+		}
+		op := fileops.Operation{Kind: fileops.OpCreate, Path: path, IsDir: isDir, Parent: firstMissing(path)}
+		if op.Parent == path {
+			op.Parent = ""
+		}
+		if err := create(path); err != nil {
+			m.SetStatus(fmt.Sprintf("Create failed: %v", err))
+			return nil
+		}
+		m.revealCreated(path)
+		m.setInfo(fmt.Sprintf("Created %s", m.displayPath(path)))
+		m.record(op)
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// startRename asks for a new name for the node under the cursor
+func (m *Model) startRename() {
+	node := m.editableNode("rename")
+	if node == nil {
+		return
+	}
+
+	p := &prompt{label: "Rename to: ", value: []rune(node.Name)}
+	p.onSubmit = func(value string) tea.Cmd {
+		if value == "" || value == node.Name {
+			return nil
+		}
+		if err := fileops.CheckName(value); err != nil {
+			m.SetStatus(fmt.Sprintf("Rename failed: %v", err))
+			return nil
+		}
+		target := filepath.Join(filepath.Dir(node.Path), value)
+		m.confirmOverwrite(target, func() {
+			m.cancelLoads(node)
+			if _, err := fileops.Rename(node.Path, value); err != nil {
+				m.SetStatus(fmt.Sprintf("Rename failed: %v", err))
+				return
+			}
+			m.moveNode(node, target)
+			m.setInfo(fmt.Sprintf("Renamed %s to %s", node.Name, value))
+			m.record(fileops.Operation{Kind: fileops.OpMove, Path: node.Path, Target: target})
+		})
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// startTransfer asks where to copy or move the node under the cursor; relative
+// destinations are resolved against the tree root and existing directories
+// receive the entry under its current name, like cp and mv
+func (m *Model) startTransfer(move bool) {
+	verb := "copy"
+	if move {
+		verb = "move"
+	}
+	node := m.editableNode(verb)
+	if node == nil {
+		return
+	}
+
+	p := &prompt{label: fmt.Sprintf("%s %s to: ", strings.ToUpper(verb[:1])+verb[1:], node.Name), value: []rune(m.displayPath(node.Path))}
+	p.onSubmit = func(value string) tea.Cmd {
+		if value == "" {
+			return nil
+		}
+		target := value
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(m.tree.Path, target)
+		}
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			target = filepath.Join(target, node.Name)
+		}
+		if target == node.Path {
+			return nil
+		}
+
+		m.confirmOverwrite(target, func() {
+			if move {
+				m.cancelLoads(node)
+				if err := fileops.Move(node.Path, target); err != nil {
+					m.SetStatus(fmt.Sprintf("Move failed: %v", err))
+					return
+				}
+				m.moveNode(node, target)
+				m.setInfo(fmt.Sprintf("Moved %s to %s", node.Name, m.displayPath(target)))
+				m.record(fileops.Operation{Kind: fileops.OpMove, Path: node.Path, Target: target})
+				return
+			}
+			if err := fileops.Copy(node.Path, target); err != nil {
+				m.SetStatus(fmt.Sprintf("Copy failed: %v", err))
+				return
+			}
+			m.revealCreated(target)
+			m.setInfo(fmt.Sprintf("Copied %s to %s", node.Name, m.displayPath(target)))
+			m.record(fileops.Operation{Kind: fileops.OpCopy, Path: node.Path, Target: target})
+		})
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// confirmDelete asks before permanently deleting the node under the cursor
+func (m *Model) confirmDelete() {
+	node := m.editableNode("delete")
+	if node == nil {
+		return
+	}
+
+	question := fmt.Sprintf("Delete %s permanently? This cannot be undone [y/N] ", node.Name)
+	if node.IsDir {
+		question = fmt.Sprintf("Delete directory %s and everything in it permanently? This cannot be undone [y/N] ", node.Name)
+	}
+	m.confirm(question, func() {
+		m.cancelLoads(node)
+		if err := fileops.Delete(node.Path); err != nil {
+			m.SetStatus(fmt.Sprintf("Delete failed: %v", err))
+			return
+		}
+		m.removeNode(node)
+		m.setInfo(fmt.Sprintf("Deleted %s", m.displayPath(node.Path)))
+	})
+}
+
+// confirm asks a yes/no question with a single key press and runs action on y
+func (m *Model) confirm(question string, action func()) {
+	m.openPrompt(&prompt{
+		label:    question,
+		menu:     true,
+		onSubmit: func(string) tea.Cmd { m.setInfo("Cancelled"); return nil },
+		onCancel: func() { m.setInfo("Cancelled") },
+		onKey: func(key string) bool {
+			if key == "y" || key == "Y" {
+				action()
+			} else {
+				m.setInfo("Cancelled")
+			}
+			return true
+		},
+	})
+}
+
+// confirmOverwrite runs action, first asking to replace target when it is an
+// existing file; the replaced file goes to the trash so the change can be
+// undone, and existing directories are never replaced
+func (m *Model) confirmOverwrite(target string, action func()) {
+	info, err := os.Lstat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		action()
+		return
+	}
+	if err == nil && info.IsDir() {
+		m.SetStatus(fmt.Sprintf("%s already exists", m.displayPath(target)))
+		return
+	}
+
+	m.confirm(fmt.Sprintf("Replace existing %s? [y/N] ", m.displayPath(target)), func() {
+		trash, ok := m.homeTrash()
+		if !ok {
+			return
+		}
+		item, err := trash.Put(target)
+		if err != nil {
+			m.SetStatus(fmt.Sprintf("Cannot replace %s: %v", m.displayPath(target), err))
+			return
+		}
+		m.record(fileops.TrashOperation(trash, item))
+		if existing := m.tree.Find(target); existing != nil {
+			existing.Detach()
+		}
+		action()
+	})
+}
+
+// editableNode returns the node under the cursor, refusing to modify the tree root
+func (m *Model) editableNode(verb string) *tree.Node {
+	node := m.currentNode()
+	if node == nil {
+		return nil
+	}
+	if node.Parent == nil {
+		m.SetStatus(fmt.Sprintf("Cannot %s the root directory", verb))
+		return nil
+	}
+	return node
+}
+
+// revealCreated adds a newly created path to the tree and moves the cursor onto it
+func (m *Model) revealCreated(path string) {
+	// Add the topmost new entry to its loaded parent; ExpandTo lists anything below it
+	for dir, name := filepath.Dir(path), filepath.Base(path); ; dir, name = filepath.Dir(dir), filepath.Base(dir) {
+		if parent := m.tree.Find(dir); parent != nil {
+			parent.Insert(name)
+			break
+		}
+		if dir == m.tree.Path || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	m.showAfterEdit(m.expandTo(path))
+}
+
+// moveNode updates the tree after node's file moved to target
+func (m *Model) moveNode(node *tree.Node, target string) {
+	parent := m.tree.Find(filepath.Dir(target))
+	if parent == nil {
+		// The destination directory is not loaded or outside the tree
+		node.Detach()
+		m.showAfterEdit(m.expandTo(target))
+		return
+	}
+	moved := node.Move(parent, filepath.Base(target))
+	if moved != nil {
+		moved = m.expandTo(target)
+	}
+	m.showAfterEdit(moved)
+}
+
+// removeNode updates the tree after node's file was deleted
+func (m *Model) removeNode(node *tree.Node) {
+	node.Detach()
+	m.showAfterEdit(nil)
+}
+
+// showAfterEdit refreshes the view after a file operation, putting the cursor
+// on focus or, without one, on the nearest node that is still shown
+func (m *Model) showAfterEdit(focus *tree.Node) {
+	previous, cursor := m.flattenedNodes, m.cursor
+	m.refreshGitStatus()
+	m.updateFlattenedNodes()
+	if focus != nil && m.isVisible(focus) {
+		m.restoreCursor(focus)
+	} else {
+		m.restoreCursorNear(previous, cursor)
+	}
+	m.previewPath = ""
+}
+
+// firstMissing returns the topmost directory of path, or path itself, that does not exist yet
+func firstMissing(path string) string {
+	missing := path
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		missing = dir
+	}
+	return missing
+}
+
+// displayPath shows paths inside the tree relative to its root
+func (m *Model) displayPath(path string) string {
+	rel, err := filepath.Rel(m.tree.Path, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"dtree/internal/tree"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filterState tracks the interactive pattern filter
+type filterState struct {
+	patterns tree.PatternSet
+	keep     map[*tree.Node]bool // Loaded nodes that match or lead to a match
+}
+
+// startFilter opens the filter prompt; the view is pruned live while typing
+func (m *Model) startFilter() {
+	previous := m.filter.patterns
+	originNodes, originCursor := m.flattenedNodes, m.cursor
+
+	p := &prompt{label: "Filter (globs, re:regex, !negate): ", value: []rune(previous.String())}
+	p.onChange = func(value string) {
+		if err := m.setFilter(value); err != nil {
+			m.SetStatus(err.Error())
+			return
+		}
+		m.status = ""
+		m.updateFlattenedNodes()
+		m.restoreCursorNear(originNodes, originCursor)
+	}
+	p.onSubmit = func(value string) tea.Cmd {
+		m.cancelDeepWalk()
+		err := m.setFilter(value)
+		if err != nil {
+			m.filter.patterns = previous
+			m.SetStatus(err.Error())
+		}
+		m.updateFlattenedNodes()
+		m.restoreCursorNear(originNodes, originCursor)
+		if len(m.filter.patterns) == 0 {
+			m.setInfo("Filter cleared")
+			return nil
+		}
+		summarize := func() {
+			m.setInfo(fmt.Sprintf("Filter: %s (%d shown)", m.filter.patterns, len(m.flattenedNodes)-1))
+		}
+		if err != nil {
+			return nil
+		}
+		// Bring in matches from directories that were never opened
+		summarize()
+		return m.loadMatches(m.filter.patterns.Match, summarize)
+	}
+	p.onCancel = func() {
+		m.filter.patterns = previous
+		m.status = ""
+		m.updateFlattenedNodes()
+		m.restoreCursorNear(originNodes, originCursor)
+	}
+	m.openPrompt(p)
+}
+
+// setFilter parses space-separated patterns; an empty value clears the filter
+func (m *Model) setFilter(value string) error {
+	patterns, err := tree.ParsePatterns(strings.Fields(value))
+	if err != nil {
+		return err
+	}
+	m.filter.patterns = patterns
+	return nil
+}
+
+// matchesFilter reports whether the node itself is selected by the filter
+func (m *Model) matchesFilter(node *tree.Node) bool {
+	rel, err := filepath.Rel(m.tree.Path, node.Path)
+	if err != nil {
+		rel = node.Name
+	}
+	return m.filter.patterns.Match(node.Name, filepath.ToSlash(rel))
+}
+
+// computeFilterKeep marks every loaded node that matches or has a matching descendant
+func (m *Model) computeFilterKeep() {
+	if len(m.filter.patterns) == 0 {
+		m.filter.keep = nil
+		return
+	}
+
+	m.filter.keep = make(map[*tree.Node]bool)
+	var mark func(node *tree.Node) bool
+	mark = func(node *tree.Node) bool {
+		keep := m.matchesFilter(node)
+		for _, child := range node.Children {
+			if mark(child) {
+				keep = true
+			}
+		}
+		if keep {
+			m.filter.keep[node] = true
+		}
+		return keep
+	}
+	mark(m.tree)
+}
+
+// passesFilter applies the pattern filter; the root is always shown
+func (m *Model) passesFilter(node *tree.Node) bool {
+	return m.filter.keep == nil || node.Parent == nil || m.filter.keep[node]
+}
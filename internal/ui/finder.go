@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"context"
+	"dtree/internal/tree"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// finderBatchSize is how many paths the background walk sends per message
+const finderBatchSize = 512
+
+// maxFinderResults bounds how many ranked results the finder keeps; further matches are only counted
+const maxFinderResults = 1000
+
+// finder is the fuzzy-find overlay opened with Ctrl+P
+type finder struct {
+	query    []rune
+	paths    []string       // Paths relative to the tree root, directories end with '/'
+	results  []finderResult // Best matches first, at most maxFinderResults
+	matched  int            // Paths matching the query, including those not kept in results
+	selected int
+	walking  bool
+	batches  <-chan []string
+	cancel   context.CancelFunc
+}
+
+// finderResult is a ranked candidate with the rune positions that matched
+type finderResult struct {
+	path      string
+	score     int
+	positions []int
+}
+
+// finderBatchMsg carries paths discovered by the background walk; nil paths means it finished
+type finderBatchMsg struct {
+	batches <-chan []string
+	paths   []string
+}
+
+// openFinder shows the overlay and starts walking the tree in the background
+func (m *Model) openFinder() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := walkPaths(ctx, m.tree.Path, m.tree.Options())
+	m.finder = &finder{walking: true, batches: batches, cancel: cancel}
+	return waitForFinderBatch(batches)
+}
+
+// closeFinder hides the overlay and stops any walk still in progress
+func (m *Model) closeFinder() {
+	if m.finder != nil {
+		m.finder.cancel()
+		m.finder = nil
+	}
+}
+
+// walkPaths streams relative paths below root in batches until the walk ends or ctx is cancelled
+func walkPaths(ctx context.Context, root string, opts *tree.Options) <-chan []string {
+	batches := make(chan []string)
+	walkOpts := *opts // Snapshot the settings before leaving the UI goroutine
+	go func() {
+		defer close(batches)
+
+		batch := make([]string, 0, finderBatchSize)
+		send := func() bool {
+			select {
+			case batches <- batch:
+				batch = make([]string, 0, finderBatchSize)
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		tree.Walk(root, &walkOpts, func(path string, isDir bool) error {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if isDir {
+				rel += "/"
+			}
+			batch = append(batch, rel)
+			if len(batch) == finderBatchSize && !send() {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if len(batch) > 0 {
+			send()
+		}
+	}()
+	return batches
+}
+
+// waitForFinderBatch receives the next batch from the background walk
+func waitForFinderBatch(batches <-chan []string) tea.Cmd {
+	return func() tea.Msg {
+		paths, ok := <-batches
+		if !ok {
+			return finderBatchMsg{batches: batches}
+		}
+		return finderBatchMsg{batches: batches, paths: paths}
+	}
+}
+
+// handleFinderBatch merges newly discovered paths and keeps listening for more
+func (m *Model) handleFinderBatch(msg finderBatchMsg) tea.Cmd {
+	if m.finder == nil || m.finder.batches != msg.batches {
+		return nil // Stale batch from a closed finder
+	}
+	if msg.paths == nil {
+		m.finder.walking = false
+		return nil
+	}
+
+	m.finder.paths = append(m.finder.paths, msg.paths...)
+	m.finder.addResults(msg.paths) // The query is unchanged, so earlier results keep their rank
+	return waitForFinderBatch(msg.batches)
+}
+
+// updateFinder handles keys while the overlay is open
+func (m *Model) updateFinder(msg tea.KeyMsg) tea.Cmd {
+	f := m.finder
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.closeFinder()
+	case "enter", "ctrl+e":
+		// With a line ("main.go:42") or Ctrl+E the selected file opens in the editor
+		_, line := splitLineSuffix(string(f.query))
+		edit := line > 0 || msg.String() == "ctrl+e"
+		m.closeFinder()
+		if f.selected >= len(f.results) {
+			return nil
+		}
+		rel := f.results[f.selected].path
+		path := filepath.Join(m.tree.Path, filepath.FromSlash(rel))
+		m.revealPath(path)
+		if edit && !strings.HasSuffix(rel, "/") {
+			return m.edit(path, line)
+		}
+	case "up", "ctrl+p", "ctrl+k":
+		if f.selected > 0 {
+			f.selected--
+		}
+	case "down", "ctrl+n", "ctrl+j":
+		if f.selected < len(f.results)-1 {
+			f.selected++
+		}
+	default:
+		if value, edited := editLine(f.query, msg); edited {
+			f.query = value
+			f.selected = 0
+			m.rankFinderResults()
+		}
+	}
+	return nil
+}
+
+// revealPath expands every ancestor of path and moves the cursor onto it
+func (m *Model) revealPath(path string) {
+	node := m.expandTo(filepath.Clean(path))
+	if node == nil {
+		m.SetStatus(fmt.Sprintf("Not found: %s", path))
+		return
+	}
+	m.updateFlattenedNodes()
+	m.restoreCursor(node)
+}
+
+// rankFinderResults scores every known path against the query, best first
+func (m *Model) rankFinderResults() {
+	f := m.finder
+	f.results, f.matched = nil, 0
+	f.addResults(f.paths)
+}
+
+// addResults scores paths against the query and merges the matches into the
+// ranked results, keeping the best maxFinderResults
+func (f *finder) addResults(paths []string) {
+	query, _ := splitLineSuffix(string(f.query))
+	var found []finderResult
+	for _, path := range paths {
+		if score, positions, ok := fuzzyMatch(query, path); ok {
+			found = append(found, finderResult{path: path, score: score, positions: positions})
+		}
+	}
+	f.matched += len(found)
+	sort.SliceStable(found, func(i, j int) bool { return found[i].better(found[j]) })
+
+	merged := make([]finderResult, 0, min(len(f.results)+len(found), maxFinderResults))
+	i, j := 0, 0
+	for len(merged) < maxFinderResults && (i < len(f.results) || j < len(found)) {
+		if j == len(found) || (i < len(f.results) && !found[j].better(f.results[i])) {
+			merged = append(merged, f.results[i])
+			i++
+		} else {
+			merged = append(merged, found[j])
+			j++
+		}
+	}
+	f.results = merged
+	if f.selected >= len(f.results) {
+		f.selected = max(len(f.results)-1, 0)
+	}
+}
+
+// better orders results by score, then shorter paths first, then by name
+func (r finderResult) better(other finderResult) bool {
+	if r.score != other.score {
+		return r.score > other.score
+	}
+	if len(r.path) != len(other.path) {
+		return len(r.path) < len(other.path)
+	}
+	return r.path < other.path
+}
+
+// fuzzyMatch reports whether every query rune appears in order in candidate and
+// scores the match, rewarding consecutive runs, word boundaries and basename hits
+func fuzzyMatch(query, candidate string) (int, []int, bool) {
+	if query == "" {
+		return 0, nil, true
+	}
+
+	smartCase := strings.ToLower(query) != query
+	q := []rune(query)
+	c := []rune(candidate)
+	baseStart := 0
+	for i, r := range c {
+		if r == '/' && i < len(c)-1 {
+			baseStart = i + 1
+		}
+	}
+
+	score := 0
+	positions := make([]int, 0, len(q))
+	qi, prev := 0, -2
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		want, have := q[qi], c[ci]
+		if !smartCase {
+			have = unicode.ToLower(have)
+		}
+		if have != want {
+			continue
+		}
+
+		points := 1
+		if ci == prev+1 {
+			points += 5
+		}
+		if ci == 0 || strings.ContainsRune("/_-. ", c[ci-1]) {
+			points += 8
+		} else if unicode.IsUpper(c[ci]) && unicode.IsLower(c[ci-1]) {
+			points += 6
+		}
+		if ci >= baseStart {
+			points += 2
+		}
+
+		score += points
+		positions = append(positions, ci)
+		prev = ci
+		qi++
+	}
+	if qi < len(q) {
+		return 0, nil, false
+	}
+	return score - len(c)/8, positions, true
+}
+
+// renderFinder draws the overlay in place of the tree
+func (m *Model) renderFinder(b *strings.Builder) {
+	f := m.finder
+	count := fmt.Sprintf("  %d/%d", f.matched, len(f.paths))
+	if f.walking {
+		count += " (indexing…)"
+	}
+	b.WriteString(m.headerStyle.Render("Find: "+string(f.query)) + m.cursorStyle.Render("█") + m.infoStyle.Render(count) + "\n")
+
+	rows := m.viewportHeight - 1
+	start := 0
+	if f.selected >= rows {
+		start = f.selected - rows + 1
+	}
+	for i := start; i < len(f.results) && i < start+rows; i++ {
+		result := f.results[i]
+		cursor := " "
+		if i == f.selected {
+			cursor = m.cursorStyle.Render(">")
+		}
+		b.WriteString(cursor + " " + m.highlightRunes(result.path, result.positions) + "\n")
+	}
+}
+
+// highlightRunes renders text with the runes at positions in the match style
+func (m *Model) highlightRunes(text string, positions []int) string {
+	var b strings.Builder
+	next := 0
+	for i, r := range []rune(text) {
+		if next < len(positions) && positions[next] == i {
+			b.WriteString(m.matchStyle.Render(string(r)))
+			next++
+		} else {
+			b.WriteString(m.fileStyle.Render(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"dtree/internal/git"
+	"dtree/internal/tree"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// gitStateColors maps each git state to its terminal color
+var gitStateColors = map[git.State]lipgloss.Color{
+	git.Modified:   lipgloss.Color("3"),
+	git.Staged:     lipgloss.Color("2"),
+	git.Untracked:  lipgloss.Color("5"),
+	git.Ignored:    lipgloss.Color("8"),
+	git.Conflicted: lipgloss.Color("1"),
+	git.Renamed:    lipgloss.Color("4"),
+}
+
+// gitStatusMsg delivers a repository state read in the background
+type gitStatusMsg struct {
+	status *git.Status
+}
+
+// loadGitStatus reads the repository state; outside a repository it is nil and decorations are disabled
+func loadGitStatus(root string) *git.Status {
+	status, err := git.Load(root)
+	if err != nil {
+		return nil
+	}
+	return status
+}
+
+// refreshGitStatus asks for the repository state to be read again once the current update is done
+func (m *Model) refreshGitStatus() {
+	m.gitStale = true
+}
+
+// gitCmd reads the repository state in the background when a refresh was
+// asked for. Refreshes asked for while a read runs are coalesced into one
+// read after it, since git status can take a while in large repositories.
+func (m *Model) gitCmd() tea.Cmd {
+	if !m.gitStale || m.gitLoading {
+		return nil
+	}
+	m.gitStale, m.gitLoading = false, true
+	root := m.tree.Path
+	return func() tea.Msg {
+		return gitStatusMsg{status: loadGitStatus(root)}
+	}
+}
+
+// handleGitStatus shows the freshly read repository state
+func (m *Model) handleGitStatus(msg gitStatusMsg) {
+	m.gitLoading = false
+	m.gitStatus = msg.status
+	current := m.currentNode()
+	m.updateFlattenedNodes() // The changed-only filter depends on the status
+	m.restoreCursor(current)
+}
+
+// toggleChangedOnly switches the review filter that only shows changed paths
+func (m *Model) toggleChangedOnly() {
+	if m.gitStatus == nil {
+		m.SetStatus("Not a git repository")
+		return
+	}
+
+	current := m.currentNode()
+	m.changedOnly = !m.changedOnly
+	if !m.changedOnly {
+		m.updateFlattenedNodes()
+		m.restoreCursor(current)
+		m.setInfo("Showing all files")
+		return
+	}
+
+	// Expand the directories leading to every change so they can be reviewed
+	changed := m.gitStatus.Changed()
+	for _, path := range changed {
+		m.expandTo(path)
+	}
+	m.updateFlattenedNodes()
+	m.restoreCursor(current)
+	if len(changed) == 0 {
+		m.setInfo("No changed files")
+	} else {
+		m.setInfo(fmt.Sprintf("Showing %d changed files", len(changed)))
+	}
+}
+
+// isChanged reports whether the node passes the changed-only filter
+func (m *Model) isChanged(node *tree.Node) bool {
+	if m.gitStatus == nil || node.Parent == nil {
+		return true
+	}
+	if m.gitStatus.Of(node.Path).IsChange() {
+		return true
+	}
+	return node.IsDir && m.gitStatus.ContainsChanges(node.Path)
+}
+
+// gitMarker renders the status decoration for a node, rolling changes up onto collapsed directories
+func (m *Model) gitMarker(node *tree.Node) string {
+	if m.gitStatus == nil {
+		return ""
+	}
+
+	state := m.gitStatus.Of(node.Path)
+	if state != git.Clean {
+		return " " + lipgloss.NewStyle().Foreground(gitStateColors[state]).Render(state.Symbol())
+	}
+	if node.IsDir && !node.IsExpanded && m.gitStatus.ContainsChanges(node.Path) {
+		return " " + lipgloss.NewStyle().Foreground(gitStateColors[git.Modified]).Render("●")
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// journal returns the persistent undo history of operations under the tree
+// root, using the user's trash for undone creations
+func (m *Model) journal() (fileops.Journal, error) {
+	path, err := fileops.DefaultJournalPath()
+	if err != nil {
+		return fileops.Journal{}, err
+	}
+	trash, err := fileops.HomeTrash()
+	if err != nil {
+		return fileops.Journal{}, err
+	}
+	return fileops.Journal{Path: path, Trash: trash, Root: m.tree.Path}, nil
+}
+
+// record adds a completed file operation to the undo history
+func (m *Model) record(op fileops.Operation) {
+	journal, err := m.journal()
+	if err == nil {
+		err = journal.Record(op)
+	}
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("%s (not recorded for undo: %v)", m.status, err))
+	}
+}
+
+// forgetTrashed drops the undo entry of an item that left the trash other than through undo
+func (m *Model) forgetTrashed(trash fileops.Trash, item fileops.TrashItem) {
+	journal, err := m.journal()
+	if err == nil {
+		err = journal.ForgetTrashed(trash, item)
+	}
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("%s (undo history not updated: %v)", m.status, err))
+	}
+}
+
+// undo reverts the last recorded file operation
+func (m *Model) undo() {
+	journal, err := m.journal()
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Undo failed: %v", err))
+		return
+	}
+	op, err := journal.Undo()
+	switch {
+	case errors.Is(err, fileops.ErrNothingToUndo):
+		m.setInfo("Nothing to undo")
+		return
+	case err != nil:
+		m.SetStatus(fmt.Sprintf("Cannot undo %s: %v", m.describeOp(op), err))
+		return
+	}
+
+	// What the operation produced is gone again; show what it started from
+	focus := ""
+	if op.Kind == fileops.OpMove || op.Kind == fileops.OpTrash {
+		focus = op.Path
+	}
+	m.showHistoryChange(focus)
+	m.setInfo(fmt.Sprintf("Undid %s (Ctrl+R to redo)", m.describeOp(op)))
+}
+
+// redo repeats the last undone file operation
+func (m *Model) redo() {
+	journal, err := m.journal()
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Redo failed: %v", err))
+		return
+	}
+	op, err := journal.Redo()
+	switch {
+	case errors.Is(err, fileops.ErrNothingToRedo):
+		m.setInfo("Nothing to redo")
+		return
+	case err != nil:
+		m.SetStatus(fmt.Sprintf("Cannot redo %s: %v", m.describeOp(op), err))
+		return
+	}
+
+	focus := op.Target
+	if op.Kind == fileops.OpCreate {
+		focus = op.Path
+	}
+	m.showHistoryChange(focus)
+	m.setInfo(fmt.Sprintf("Redid %s", m.describeOp(op)))
+}
+
+// showHistoryChange re-reads the tree after an undo or redo and moves the cursor onto focus, if set
+func (m *Model) showHistoryChange(focus string) {
+	m.reload()
+	if focus == "" {
+		return
+	}
+	if node := m.expandTo(focus); node != nil {
+		m.updateFlattenedNodes()
+		if m.isVisible(node) {
+			m.restoreCursor(node)
+		}
+	}
+}
+
+// describeOp summarizes an operation with paths relative to the tree root
+func (m *Model) describeOp(op fileops.Operation) string {
+	switch op.Kind {
+	case fileops.OpGroup:
+		first := op.Ops[0]
+		if first.Kind == fileops.OpMove || first.Kind == fileops.OpCopy {
+			return fmt.Sprintf("%s of %d entries to %s", first.Kind, len(op.Ops), m.displayPath(filepath.Dir(first.Target)))
+		}
+		return fmt.Sprintf("%s of %d entries", first.Kind, len(op.Ops))
+	case fileops.OpMove, fileops.OpCopy:
+		return fmt.Sprintf("%s of %s to %s", op.Kind, m.displayPath(op.Path), m.displayPath(op.Target))
+	default:
+		return fmt.Sprintf("%s of %s", op.Kind, m.displayPath(op.Path))
+	}
+}
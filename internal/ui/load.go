@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"context"
+	"dtree/internal/tree"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spinnerInterval is how often the loading spinner advances
+const spinnerInterval = 100 * time.Millisecond
+
+// spinnerFrames animate the marker next to directories that are still loading
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// errLoadCancelled marks a directory whose listing was stopped before it finished
+var errLoadCancelled = errors.New("load cancelled")
+
+// loadState is a directory listing streaming in from the background
+type loadState struct {
+	batches <-chan tree.ChildBatch
+	cancel  context.CancelFunc
+	entries int  // Children received so far
+	retry   bool // The directory failed to load before
+}
+
+// loadMsg delivers one batch of a directory listing; done means the listing ended
+type loadMsg struct {
+	node    *tree.Node
+	batches <-chan tree.ChildBatch
+	batch   tree.ChildBatch
+	done    bool
+}
+
+// spinnerMsg advances the loading spinner
+type spinnerMsg struct{}
+
+// startLoad expands node and starts listing it in the background
+func (m *Model) startLoad(node *tree.Node) tea.Cmd {
+	node.IsExpanded = true
+	if _, loading := m.loads[node]; loading {
+		return nil
+	}
+
+	retry := node.Err != nil
+	node.Children, node.Err = nil, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &loadState{batches: node.StreamChildren(ctx), cancel: cancel, retry: retry}
+	if m.loads == nil {
+		m.loads = make(map[*tree.Node]*loadState)
+	}
+	m.loads[node] = state
+	return tea.Batch(waitForLoad(node, state.batches), m.spin())
+}
+
+// cancelLoads stops the listings in progress at or below root, marking those
+// directories as incomplete, and returns how many were stopped
+func (m *Model) cancelLoads(root *tree.Node) int {
+	count := 0
+	for node, state := range m.loads {
+		if !isWithin(node, root) {
+			continue
+		}
+		state.cancel()
+		node.Err = errLoadCancelled
+		delete(m.loads, node)
+		count++
+	}
+	return count
+}
+
+// isWithin reports whether node is root or one of its descendants
+func isWithin(node, root *tree.Node) bool {
+	for ; node != nil; node = node.Parent {
+		if node == root {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoading reports whether node is still being listed
+func (m *Model) isLoading(node *tree.Node) bool {
+	_, loading := m.loads[node]
+	return loading
+}
+
+// expandTo expands the directories leading to path like tree.ExpandTo, without
+// reading directories again that are being listed in the background
+func (m *Model) expandTo(path string) *tree.Node {
+	return m.tree.ExpandToSkipping(path, m.isLoading)
+}
+
+// waitForLoad receives the next batch of a directory listing
+func waitForLoad(node *tree.Node, batches <-chan tree.ChildBatch) tea.Cmd {
+	return func() tea.Msg {
+		batch, ok := <-batches
+		return loadMsg{node: node, batches: batches, batch: batch, done: !ok}
+	}
+}
+
+// handleLoad attaches streamed children and keeps listening until the listing ends
+func (m *Model) handleLoad(msg loadMsg) tea.Cmd {
+	state, ok := m.loads[msg.node]
+	if !ok || state.batches != msg.batches {
+		return nil // Stale batch from a cancelled load
+	}
+	node := msg.node
+
+	if msg.done {
+		state.cancel()
+		delete(m.loads, node)
+		if node.Err != nil {
+			m.SetStatus(fmt.Sprintf("Cannot read %s: %s", node.Path, node.ErrorSummary()))
+		} else if state.retry {
+			m.setInfo(fmt.Sprintf("Loaded %s", node.Path))
+		}
+		return nil
+	}
+
+	current := m.currentNode()
+	node.AddChildren(msg.batch.Children)
+	if msg.batch.Err != nil {
+		node.Err = msg.batch.Err
+	}
+	state.entries += len(msg.batch.Children)
+	m.updateFlattenedNodes()
+	m.restoreCursor(current)
+	return waitForLoad(node, msg.batches)
+}
+
+// spin schedules the next spinner frame unless one is already pending
+func (m *Model) spin() tea.Cmd {
+	if m.spinning {
+		return nil
+	}
+	m.spinning = true
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return spinnerMsg{}
+	})
+}
+
+// handleSpinner advances the spinner while any directory is loading
+func (m *Model) handleSpinner() tea.Cmd {
+	m.spinning = false
+	if len(m.loads) == 0 {
+		return nil
+	}
+	m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+	return m.spin()
+}
+
+// loadingSuffix renders the spinner and entry count of a directory being listed
+func (m *Model) loadingSuffix(node *tree.Node) string {
+	state, ok := m.loads[node]
+	if !ok {
+		return ""
+	}
+	return m.ignoredStyle.Render(fmt.Sprintf(" %s loading (%d entries, Esc to cancel)", spinnerFrames[m.spinnerFrame], state.entries))
+}
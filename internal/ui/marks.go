@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetClipboard directs OSC 52 clipboard sequences to w instead of stderr
+func (m *Model) SetClipboard(w io.Writer) {
+	m.clipboard = w
+}
+
+// isMarked reports whether node is marked or inside the active visual range
+func (m *Model) isMarked(index int, node *tree.Node) bool {
+	if m.marks[node.Path] {
+		return true
+	}
+	if !m.visual {
+		return false
+	}
+	low, high := min(m.visualAnchor, m.cursor), max(m.visualAnchor, m.cursor)
+	return index >= low && index <= high && node.Parent != nil
+}
+
+// setMark marks or unmarks a node; the tree root cannot be marked
+func (m *Model) setMark(node *tree.Node, marked bool) {
+	if node.Parent == nil {
+		return
+	}
+	if !marked {
+		delete(m.marks, node.Path)
+		return
+	}
+	if m.marks == nil {
+		m.marks = make(map[string]bool)
+	}
+	m.marks[node.Path] = true
+}
+
+// toggleMark flips the mark under the cursor and moves down, like ranger's space
+func (m *Model) toggleMark() {
+	node := m.currentNode()
+	if node == nil {
+		return
+	}
+	m.setMark(node, !m.marks[node.Path])
+	m.moveCursor(1)
+}
+
+// startMarkPattern asks for a pattern and marks every shown node matching it
+func (m *Model) startMarkPattern() {
+	p := &prompt{label: "Mark matching (globs, re:regex, !negate): "}
+	p.onSubmit = func(value string) tea.Cmd {
+		if value == "" {
+			return nil
+		}
+		patterns, err := tree.ParsePatterns(strings.Fields(value))
+		if err != nil {
+			m.SetStatus(err.Error())
+			return nil
+		}
+		count := 0
+		for _, node := range m.flattenedNodes {
+			rel, err := filepath.Rel(m.tree.Path, node.Path)
+			if err != nil {
+				rel = node.Name
+			}
+			if node.Parent != nil && patterns.Match(node.Name, filepath.ToSlash(rel)) {
+				m.setMark(node, true)
+				count++
+			}
+		}
+		m.setInfo(fmt.Sprintf("Marked %d entries matching %s", count, patterns))
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// invertMarks flips the mark of every shown node
+func (m *Model) invertMarks() {
+	for _, node := range m.flattenedNodes {
+		m.setMark(node, !m.marks[node.Path])
+	}
+}
+
+// toggleVisual starts a visual range at the cursor, or marks the range and ends it
+func (m *Model) toggleVisual() {
+	if !m.visual {
+		m.visual, m.visualAnchor = true, m.cursor
+		return
+	}
+	for i, node := range m.flattenedNodes {
+		if m.isMarked(i, node) {
+			m.setMark(node, true)
+		}
+	}
+	m.visual = false
+}
+
+// clearSelection ends visual mode or drops all marks, reporting whether there was anything to clear
+func (m *Model) clearSelection() bool {
+	switch {
+	case m.visual:
+		m.visual = false
+	case len(m.marks) > 0:
+		m.marks = nil
+		m.setInfo("Marks cleared")
+	default:
+		return false
+	}
+	return true
+}
+
+// pruneMarks drops marks on paths that are no longer in the tree, e.g. after
+// the entry was renamed or deleted
+func (m *Model) pruneMarks() {
+	for path := range m.marks {
+		if m.tree.Find(path) == nil {
+			delete(m.marks, path)
+		}
+	}
+	if m.visual && m.visualAnchor >= len(m.flattenedNodes) {
+		m.visualAnchor = len(m.flattenedNodes) - 1
+	}
+}
+
+// markedNodes returns the loaded marked nodes in tree order, leaving out
+// nodes inside a marked directory since operations on it include them
+func (m *Model) markedNodes() []*tree.Node {
+	var nodes []*tree.Node
+	var collect func(node *tree.Node)
+	collect = func(node *tree.Node) {
+		if m.marks[node.Path] {
+			nodes = append(nodes, node)
+			return
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(m.tree)
+	return nodes
+}
+
+// targets returns the marked nodes, or the node under the cursor when nothing is marked
+func (m *Model) targets() []*tree.Node {
+	if nodes := m.markedNodes(); len(nodes) > 0 {
+		return nodes
+	}
+	if node := m.currentNode(); node != nil {
+		return []*tree.Node{node}
+	}
+	return nil
+}
+
+// copyPaths puts the paths of the marked nodes, or the cursor's, on the
+// clipboard using OSC 52, which also works over SSH
+func (m *Model) copyPaths() tea.Cmd {
+	nodes := m.targets()
+	if len(nodes) == 0 {
+		return nil
+	}
+	paths := make([]string, len(nodes))
+	for i, node := range nodes {
+		paths[i] = node.Path
+	}
+
+	seq := osc52.New(strings.Join(paths, "\n"))
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	out := m.clipboard
+	if out == nil {
+		out = os.Stderr // The renderer owns stdout
+	}
+	if len(paths) == 1 {
+		m.setInfo(fmt.Sprintf("Copied %s to the clipboard", paths[0]))
+	} else {
+		m.setInfo(fmt.Sprintf("Copied %d paths to the clipboard", len(paths)))
+	}
+	return func() tea.Msg {
+		seq.WriteTo(out)
+		return nil
+	}
+}
+
+// openTargets opens the marked nodes, or the one under the cursor, with their
+// openers; openers that need the terminal take turns
+func (m *Model) openTargets() tea.Cmd {
+	nodes := m.targets()
+	var cmds []tea.Cmd
+	failed := 0
+	var firstErr error
+	for _, node := range nodes {
+		cmd, err := m.open(node.Path, m.searchLine(node))
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	switch {
+	case failed == 1 && len(nodes) == 1:
+		m.SetStatus(fileops.FormatOpenError(nodes[0].Path, firstErr))
+	case failed > 0:
+		m.SetStatus(fmt.Sprintf("Could not open %d of %d entries: %v", failed, len(nodes), firstErr))
+	case len(nodes) > 1:
+		m.setInfo(fmt.Sprintf("Opened %d entries", len(nodes)))
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Sequence(cmds...)
+}
+
+// startBulkTransfer asks for a directory to copy or move every marked node into
+func (m *Model) startBulkTransfer(move bool) {
+	nodes := m.markedNodes()
+	verb := "Copy"
+	if move {
+		verb = "Move"
+	}
+	dir := m.currentNode()
+	if dir != nil && !dir.IsDir && dir.Parent != nil {
+		dir = dir.Parent
+	}
+
+	p := &prompt{label: fmt.Sprintf("%s %d marked entries to directory: ", verb, len(nodes))}
+	if dir != nil {
+		p.value = []rune(m.displayPath(dir.Path))
+	}
+	p.onSubmit = func(value string) tea.Cmd {
+		if value == "" {
+			return nil
+		}
+		target := value
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(m.tree.Path, target)
+		}
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			m.SetStatus(fmt.Sprintf("%s is not a directory", value))
+			return nil
+		}
+
+		var ops []fileops.Operation
+		m.bulk(nodes, verb, move, func(node *tree.Node) error {
+			op := fileops.Operation{Kind: fileops.OpCopy, Path: node.Path, Target: filepath.Join(target, node.Name)}
+			var err error
+			if move {
+				op.Kind = fileops.OpMove
+				m.cancelLoads(node)
+				err = fileops.Move(op.Path, op.Target)
+			} else {
+				err = fileops.Copy(op.Path, op.Target)
+			}
+			if err == nil {
+				ops = append(ops, op)
+			}
+			return err
+		})
+		m.reload() // Bring in the new entries wherever the destination is loaded
+		dest := m.expandTo(target)
+		if dest != nil && !dest.IsExpanded {
+			dest.IsExpanded = true
+			if len(dest.Children) == 0 && !m.isLoading(dest) {
+				dest.LoadChildren()
+			}
+		}
+		m.showAfterEdit(dest)
+		if len(ops) > 0 {
+			m.record(fileops.GroupOperation(ops))
+		}
+		return nil
+	}
+	m.openPrompt(p)
+}
+
+// confirmBulkDelete asks before moving every marked node to the trash, or deleting them permanently
+func (m *Model) confirmBulkDelete(permanent bool) {
+	nodes := m.markedNodes()
+	question := fmt.Sprintf("Move %d marked entries to the trash? [y/N] ", len(nodes))
+	if permanent {
+		question = fmt.Sprintf("Delete %d marked entries permanently? This cannot be undone [y/N] ", len(nodes))
+	}
+	m.confirm(question, func() {
+		trash, ok := m.homeTrash()
+		if !ok {
+			return
+		}
+		var ops []fileops.Operation
+		verb := "Trash"
+		if permanent {
+			verb = "Delete"
+		}
+		m.bulk(nodes, verb, true, func(node *tree.Node) error {
+			m.cancelLoads(node)
+			if permanent {
+				return fileops.Delete(node.Path)
+			}
+			item, err := trash.Put(node.Path)
+			if err == nil {
+				ops = append(ops, fileops.TrashOperation(trash, item))
+			}
+			return err
+		})
+		m.showAfterEdit(nil)
+		if len(ops) > 0 {
+			m.record(fileops.GroupOperation(ops))
+		}
+	})
+}
+
+// bulk applies action to each node, detaching the nodes it removed from
+// their place, clears the marks and summarizes the result in the status line
+func (m *Model) bulk(nodes []*tree.Node, verb string, detach bool, action func(*tree.Node) error) {
+	failed := 0
+	var firstErr error
+	for _, node := range nodes {
+		if err := action(node); err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if detach {
+			node.Detach()
+		}
+	}
+	m.marks = nil
+
+	if failed > 0 {
+		m.SetStatus(fmt.Sprintf("%s failed for %d of %d entries: %v", verb, failed, len(nodes), firstErr))
+	} else {
+		m.setInfo(fmt.Sprintf("%s done for %d entries", verb, len(nodes)))
+	}
+}
@@ -1,7 +1,14 @@
 package ui
 
 import (
+	"dtree/internal/columns"
+	"dtree/internal/fileops"
+	"dtree/internal/git"
+	"dtree/internal/preview"
 	"dtree/internal/tree"
+	"dtree/internal/watch"
+	"fmt"
+	"io"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -15,6 +22,7 @@ type Model struct {
 	initialDepth   int
 	rootPath       string
 	status         string // Status message for user feedback
+	statusIsInfo   bool   // Render status as information rather than an error
 
 	// Viewport for scrolling
 	viewportHeight int // Available height for content display
@@ -22,12 +30,59 @@ type Model struct {
 	terminalHeight int // Total terminal height
 	terminalWidth  int // Total terminal width
 
+	// Git decorations
+	gitStatus   *git.Status // nil outside a git repository
+	changedOnly bool        // Only show changed paths and their ancestors
+	gitLoading  bool        // A status read is running in the background
+	gitStale    bool        // A refresh was asked for since the last read started
+
+	// Input and search
+	prompt *prompt // Active input line, nil when not prompting
+	search searchState
+	filter filterState
+	finder *finder    // Fuzzy finder overlay, nil when closed
+	trash  *trashView // Trash listing shown instead of the tree, nil when closed
+	deep   *deepWalk  // Deep search or filter looking through unopened directories, nil when idle
+
+	// Preview pane
+	showPreview bool
+	previewPath string           // Path of the most recently requested preview
+	preview     *preview.Preview // Last loaded preview, may lag behind previewPath
+
+	// Metadata columns
+	columns     columns.Set // Columns currently shown
+	longColumns columns.Set // Columns the L key toggles on
+	du          duState     // Disk-usage mode
+
+	// Directories being listed in the background
+	loads        map[*tree.Node]*loadState
+	spinnerFrame int
+	spinning     bool // A spinner tick is pending
+
+	// Selection for bulk operations
+	marks        map[string]bool // Marked paths
+	visual       bool            // Extending a range from visualAnchor to the cursor
+	visualAnchor int
+	clipboard    io.Writer // Receives OSC 52 sequences, stderr when nil
+
+	openers fileops.Openers // Rules for opening files, ahead of the system opener
+
+	watcher      *watch.Watcher // Refreshes expanded directories on change, nil when not watching
+	expandedDirs []string       // Expanded directories in the view, the set to watch
+	watchStale   bool           // expandedDirs changed since the watches were last updated
+
 	// Styling
-	dirStyle    lipgloss.Style
-	fileStyle   lipgloss.Style
-	cursorStyle lipgloss.Style
-	headerStyle lipgloss.Style
-	errorStyle  lipgloss.Style
+	dirStyle     lipgloss.Style
+	fileStyle    lipgloss.Style
+	ignoredStyle lipgloss.Style
+	cursorStyle  lipgloss.Style
+	headerStyle  lipgloss.Style
+	errorStyle   lipgloss.Style
+	infoStyle    lipgloss.Style
+	matchStyle   lipgloss.Style
+	linkStyle    lipgloss.Style
+	markStyle    lipgloss.Style
+	syntaxStyles map[preview.TokenKind]lipgloss.Style // Preview highlighting, keyed by token kind
 
 	// Vim-style navigation state
 	pendingG bool // Track if 'g' was pressed for 'gg' sequence
@@ -47,26 +102,51 @@ func New(rootTree *tree.Node, initialDepth int, rootPath string) *Model {
 		terminalHeight: 1000, // Large default - will be updated by WindowSizeMsg
 		terminalWidth:  80,   // Default fallback
 
-		dirStyle:    lipgloss.NewStyle().Bold(true),
-		fileStyle:   lipgloss.NewStyle(),
-		cursorStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true),
-		headerStyle: lipgloss.NewStyle(),
-		errorStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		dirStyle:     lipgloss.NewStyle().Bold(true),
+		fileStyle:    lipgloss.NewStyle(),
+		ignoredStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		cursorStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true),
+		headerStyle:  lipgloss.NewStyle(),
+		errorStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		infoStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+		matchStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(lipgloss.Color("3")),
+		linkStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+		markStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true),
+		syntaxStyles: map[preview.TokenKind]lipgloss.Style{
+			preview.TokenKeyword:  lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
+			preview.TokenType:     lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+			preview.TokenString:   lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+			preview.TokenNumber:   lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+			preview.TokenComment:  lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+			preview.TokenKey:      lipgloss.NewStyle().Foreground(lipgloss.Color("4")),
+			preview.TokenVariable: lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+			preview.TokenHeading:  lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Bold(true),
+			preview.TokenCode:     lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+			preview.TokenEmphasis: lipgloss.NewStyle().Italic(true),
+			preview.TokenLink:     lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Underline(true),
+		},
 	}
 
+	if git.InWorkTree(m.tree.Path) {
+		m.refreshGitStatus() // Read in the background once Init runs
+	}
 	m.updateFlattenedNodes()
+	m.reportLoadErrors()
 	return m
 }
 
 // Init initializes the model (required by Bubbletea)
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.previewCmd(), m.duCmd(), m.watchCmd(), m.gitCmd())
 }
 
 // updateFlattenedNodes rebuilds the flattened view for navigation
 func (m *Model) updateFlattenedNodes() {
 	m.flattenedNodes = []*tree.Node{}
+	m.computeFilterKeep()
 	m.flattenRecursive(m.tree)
+	m.pruneMarks()
+	m.trackExpanded()
 }
 
 // flattenRecursive recursively adds visible nodes to the flattened list
@@ -74,14 +154,165 @@ func (m *Model) flattenRecursive(node *tree.Node) {
 	m.flattenedNodes = append(m.flattenedNodes, node)
 	if node.IsExpanded {
 		for _, child := range node.Children {
-			m.flattenRecursive(child)
+			if m.isVisible(child) {
+				m.flattenRecursive(child)
+			}
 		}
 	}
 }
 
+// isVisible applies the active view filters to a node
+func (m *Model) isVisible(node *tree.Node) bool {
+	return (!m.changedOnly || m.isChanged(node)) && m.passesFilter(node)
+}
+
+// isLastVisible reports whether no visible sibling follows the node
+func (m *Model) isLastVisible(node *tree.Node) bool {
+	if node.Parent == nil {
+		return true
+	}
+
+	siblings := node.Parent.Children
+	for i := len(siblings) - 1; i >= 0; i-- {
+		if m.isVisible(siblings[i]) {
+			return siblings[i] == node
+		}
+	}
+	return true
+}
+
 // SetStatus sets the status message
 func (m *Model) SetStatus(status string) {
 	m.status = status
+	m.statusIsInfo = false
+}
+
+// setInfo sets a non-error status message
+func (m *Model) setInfo(info string) {
+	m.status = info
+	m.statusIsInfo = true
+}
+
+// currentNode returns the node under the cursor, or nil for an empty view
+func (m *Model) currentNode() *tree.Node {
+	if m.cursor < 0 || m.cursor >= len(m.flattenedNodes) {
+		return nil
+	}
+	return m.flattenedNodes[m.cursor]
+}
+
+// reload re-reads all loaded directories and keeps the cursor on the same
+// node, or the nearest one still shown when it disappeared
+func (m *Model) reload() {
+	m.reloadNode(m.tree)
+}
+
+// reloadNode re-reads node and the loaded directories below it, keeping
+// expansion state and the cursor like reload
+func (m *Model) reloadNode(node *tree.Node) {
+	previous, cursor := m.flattenedNodes, m.cursor
+	m.cancelLoads(node) // Reload lists those directories again
+	node.Reload()
+	m.refreshGitStatus()
+	m.updateFlattenedNodes()
+	m.restoreCursorNear(previous, cursor)
+	m.previewPath = "" // Contents may have changed on disk
+}
+
+// reloadCurrent re-reads the directory under the cursor, or the file's parent
+func (m *Model) reloadCurrent() {
+	node := m.currentNode()
+	if node == nil {
+		return
+	}
+	if !node.IsDir && node.Parent != nil {
+		node = node.Parent
+	}
+	m.reloadNode(node)
+	m.setInfo(fmt.Sprintf("Reloaded %s", node.Path))
+}
+
+// restoreCursor moves the cursor back onto node after the flattened view changed
+func (m *Model) restoreCursor(node *tree.Node) {
+	for i, n := range m.flattenedNodes {
+		if n == node {
+			m.cursor = i
+			m.adjustViewportToCursor()
+			return
+		}
+	}
+	m.moveCursor(0)
+}
+
+// reportLoadErrors shows the first directory that could not be read in the status line
+func (m *Model) reportLoadErrors() {
+	var failed []*tree.Node
+	var collect func(node *tree.Node)
+	collect = func(node *tree.Node) {
+		if node.Err != nil {
+			failed = append(failed, node)
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(m.tree)
+
+	switch len(failed) {
+	case 0:
+	case 1:
+		m.SetStatus(fmt.Sprintf("Cannot read %s: %s", failed[0].Path, failed[0].ErrorSummary()))
+	default:
+		m.SetStatus(fmt.Sprintf("Cannot read %s: %s (and %d more)", failed[0].Path, failed[0].ErrorSummary(), len(failed)-1))
+	}
+}
+
+// restoreCursorNear moves the cursor onto previous[cursor], or when that node
+// is no longer shown, onto the closest node of the previous view that still is
+func (m *Model) restoreCursorNear(previous []*tree.Node, cursor int) {
+	index := make(map[*tree.Node]int, len(m.flattenedNodes))
+	for i, node := range m.flattenedNodes {
+		index[node] = i
+	}
+
+	// Look at the following node before the preceding one at each distance
+	for distance := 0; distance < len(previous); distance++ {
+		for _, i := range []int{cursor + distance, cursor - distance} {
+			if i < 0 || i >= len(previous) {
+				continue
+			}
+			if newIndex, ok := index[previous[i]]; ok {
+				m.cursor = newIndex
+				m.adjustViewportToCursor()
+				return
+			}
+		}
+	}
+	m.moveCursor(0)
+}
+
+// toggleHidden shows or hides dotfiles
+func (m *Model) toggleHidden() {
+	opts := m.tree.Options()
+	opts.HideHidden = !opts.HideHidden
+	m.reload()
+	if opts.HideHidden {
+		m.setInfo("Hiding hidden files")
+	} else {
+		m.setInfo("Showing hidden files")
+	}
+}
+
+// toggleIgnored shows or hides entries matched by ignore files
+func (m *Model) toggleIgnored() {
+	opts := m.tree.Options()
+	opts.HideIgnored = !opts.HideIgnored
+	m.reload()
+	if opts.HideIgnored {
+		m.setInfo("Hiding ignored entries")
+	} else {
+		m.setInfo("Showing ignored entries")
+	}
 }
 
 // updateViewportHeight calculates available height for content
@@ -93,8 +324,12 @@ func (m *Model) updateViewportHeight() {
 	if m.status != "" {
 		statusLines = 1
 	}
+	promptLines := 0
+	if m.prompt != nil {
+		promptLines = 1
+	}
 
-	availableHeight := m.terminalHeight - headerLines - controlLines - statusLines
+	availableHeight := m.terminalHeight - headerLines - controlLines - statusLines - promptLines
 
 	// Only constrain viewport if we have a reasonable terminal height
 	// This ensures tests and very tall terminals show all content
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetOpeners sets the rules that decide how files are opened; files no rule
+// matches open with the system's default application
+func (m *Model) SetOpeners(openers fileops.Openers) {
+	m.openers = openers
+}
+
+// openCurrent opens node with its opener, passing the line of a "file:42" search
+func (m *Model) openCurrent(node *tree.Node) tea.Cmd {
+	cmd, err := m.open(node.Path, m.searchLine(node))
+	if err != nil {
+		m.SetStatus(fileops.FormatOpenError(node.Path, err))
+	} else {
+		m.status = ""
+	}
+	return cmd
+}
+
+// open runs the opener rule for path. Detached openers start right away;
+// foreground and pager openers are returned as a command that suspends the
+// TUI while they run.
+func (m *Model) open(path string, line int) (tea.Cmd, error) {
+	rule, err := m.openers.Rule(m.tree.Path, path)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Mode == fileops.OpenDetached {
+		return nil, rule.Start(path, line)
+	}
+	return tea.Exec(rule.TerminalCommand(path, line), func(err error) tea.Msg {
+		return execMsg{path: path, action: "open", err: err}
+	}), nil
+}
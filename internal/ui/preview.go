@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"dtree/internal/preview"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPreviewLines caps how much content a preview loads
+const maxPreviewLines = 500
+
+// previewSeparator divides the tree from the preview pane
+const previewSeparator = " │ "
+
+// previewMsg delivers a preview loaded in the background
+type previewMsg struct {
+	preview *preview.Preview
+}
+
+// SetPreview enables or disables the preview pane
+func (m *Model) SetPreview(enabled bool) {
+	m.showPreview = enabled
+	m.previewPath = ""
+}
+
+// togglePreview shows or hides the preview pane
+func (m *Model) togglePreview() {
+	m.SetPreview(!m.showPreview)
+	if !m.showPreview {
+		m.preview = nil
+	}
+}
+
+// previewCmd loads the node under the cursor when it differs from the one shown
+func (m *Model) previewCmd() tea.Cmd {
+	if !m.showPreview {
+		return nil
+	}
+	node := m.currentNode()
+	if node == nil || node.Path == m.previewPath {
+		return nil
+	}
+
+	m.previewPath = node.Path
+	path, maxLines := node.Path, min(max(m.viewportHeight, 20), maxPreviewLines)
+	return func() tea.Msg {
+		return previewMsg{preview: preview.Load(path, maxLines)}
+	}
+}
+
+// handlePreview stores a loaded preview unless the cursor already moved on
+func (m *Model) handlePreview(msg previewMsg) {
+	if msg.preview.Path == m.previewPath {
+		m.preview = msg.preview
+	}
+}
+
+// treePaneWidth is the width available to tree lines
+func (m *Model) treePaneWidth() int {
+	if !m.showPreview {
+		return m.terminalWidth
+	}
+	return max(m.terminalWidth/2, 20)
+}
+
+// previewPaneWidth is the width available to the preview beside the tree
+func (m *Model) previewPaneWidth() int {
+	return max(m.terminalWidth-m.treePaneWidth()-lipgloss.Width(previewSeparator), 10)
+}
+
+// previewRows renders the preview pane content, one entry per screen row
+func (m *Model) previewRows() []string {
+	p := m.preview
+	if p == nil || p.Path != m.previewPath {
+		return []string{m.infoStyle.Render("Loading preview…")}
+	}
+
+	rows := []string{m.headerStyle.Render(p.Info)}
+	if p.Err != nil {
+		return append(rows, m.errorStyle.Render(p.Err.Error()))
+	}
+
+	switch p.Kind {
+	case preview.KindText:
+		numberWidth := len(fmt.Sprint(len(p.Lines)))
+		highlighter := preview.NewHighlighter(p.Language)
+		for i, line := range p.Lines {
+			number := m.ignoredStyle.Render(fmt.Sprintf("%*d ", numberWidth, i+1))
+			if highlighter != nil {
+				line = m.renderTokens(highlighter.Line(line))
+			}
+			rows = append(rows, number+line)
+		}
+	case preview.KindDirectory:
+		for _, line := range p.Lines {
+			style := m.fileStyle
+			if strings.HasSuffix(line, "/") {
+				style = m.dirStyle
+			}
+			rows = append(rows, style.Render(line))
+		}
+		if len(p.Lines) == 0 {
+			rows = append(rows, m.ignoredStyle.Render("(empty directory)"))
+		}
+	case preview.KindSpecial:
+		rows = append(rows, m.ignoredStyle.Render("(not a regular file, contents not shown)"))
+	default:
+		rows = append(rows, p.Lines...)
+	}
+
+	if p.Truncated {
+		rows = append(rows, m.ignoredStyle.Render("…"))
+	}
+	return rows
+}
+
+// renderTokens styles a highlighted line, leaving plain tokens unstyled
+func (m *Model) renderTokens(tokens []preview.Token) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		if style, ok := m.syntaxStyles[token.Kind]; ok {
+			b.WriteString(style.Render(token.Text))
+		} else {
+			b.WriteString(token.Text)
+		}
+	}
+	return b.String()
+}
+
+// joinPreview lays tree lines and preview rows side by side
+func (m *Model) joinPreview(treeLines []string) []string {
+	rows := m.previewRows()
+	height := max(len(treeLines), min(len(rows), m.viewportHeight))
+
+	treeWidth, previewWidth := m.treePaneWidth(), m.previewPaneWidth()
+	clipTree := lipgloss.NewStyle().MaxWidth(treeWidth)
+	clipPreview := lipgloss.NewStyle().MaxWidth(previewWidth)
+
+	lines := make([]string, height)
+	for i := range lines {
+		left := ""
+		if i < len(treeLines) {
+			left = clipTree.Render(treeLines[i])
+		}
+		left += strings.Repeat(" ", max(treeWidth-lipgloss.Width(left), 0))
+
+		right := ""
+		if i < len(rows) {
+			right = clipPreview.Render(rows[i])
+		}
+		lines[i] = left + m.ignoredStyle.Render(previewSeparator) + right
+	}
+	return lines
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prompt is a single-line text input rendered above the controls
+type prompt struct {
+	label    string
+	value    []rune
+	onChange func(value string)           // Called after every edit (may be nil)
+	onSubmit func(value string) tea.Cmd   // Called on Enter
+	onCancel func()                       // Called on Esc (may be nil)
+	onKey    func(key string) (used bool) // Extra prompt-specific keys (may be nil)
+	menu     bool                         // Keys go to onKey instead of the input; a used key closes the prompt
+}
+
+// openPrompt starts reading input; other keys are routed to the prompt until it closes
+func (m *Model) openPrompt(p *prompt) {
+	m.prompt = p
+	m.updateViewportHeight()
+	m.adjustViewportToCursor()
+}
+
+// closePrompt hides the input line
+func (m *Model) closePrompt() {
+	m.prompt = nil
+	m.updateViewportHeight()
+	m.adjustViewportToCursor()
+}
+
+// updatePrompt handles a key press while a prompt is open
+func (m *Model) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	p := m.prompt
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.closePrompt()
+		return p.onSubmit(string(p.value))
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.closePrompt()
+		if p.onCancel != nil {
+			p.onCancel()
+		}
+		return nil
+	default:
+		if p.menu {
+			// Close first so the handler may open a follow-up prompt
+			m.closePrompt()
+			if !p.onKey(msg.String()) {
+				m.openPrompt(p)
+			}
+			return nil
+		}
+		value, edited := editLine(p.value, msg)
+		if edited {
+			p.value = value
+		} else if p.onKey == nil || !p.onKey(msg.String()) {
+			return nil
+		}
+	}
+
+	if p.onChange != nil {
+		p.onChange(string(p.value))
+	}
+	return nil
+}
+
+// editLine applies basic line-editing keys to value, reporting whether the key was consumed
+func editLine(value []rune, msg tea.KeyMsg) ([]rune, bool) {
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if len(value) > 0 {
+			value = value[:len(value)-1]
+		}
+	case tea.KeyCtrlU:
+		value = value[:0]
+	case tea.KeySpace:
+		value = append(value, ' ')
+	case tea.KeyRunes:
+		value = append(value, msg.Runes...)
+	default:
+		return value, false
+	}
+	return value, true
+}
+
+// renderPrompt draws the input line with a block cursor
+func (m *Model) renderPrompt() string {
+	return m.headerStyle.Render(m.prompt.label+string(m.prompt.value)) + m.cursorStyle.Render("█")
+}
@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"context"
+	"dtree/internal/tree"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxDeepSearchNodes bounds how many entries a deep search or filter reads from disk
+const maxDeepSearchNodes = 50000
+
+// maxDeepMatches bounds how many matches a deep search or filter reveals in the tree
+const maxDeepMatches = 1000
+
+// searchState tracks the active "/" search
+type searchState struct {
+	query  string
+	deep   bool // Also search directories that are not loaded yet
+	origin int  // Cursor position when the prompt was opened
+}
+
+// startSearch opens the vim-style search prompt
+func (m *Model) startSearch() {
+	m.search.origin = m.cursor
+	m.search.query = ""
+
+	p := &prompt{label: m.searchLabel()}
+	p.onChange = func(value string) {
+		m.search.query = value
+		p.label = m.searchLabel()
+		m.cursor = m.search.origin
+		m.jumpToMatch(1, true)
+	}
+	p.onSubmit = func(value string) tea.Cmd {
+		m.search.query = value
+		m.cancelDeepWalk()
+		if value == "" {
+			m.status = ""
+			return nil
+		}
+		m.jumpToMatch(1, true)
+		if !m.search.deep {
+			return nil
+		}
+		match := func(name, _ string) bool {
+			_, ok := matchRange(value, name)
+			return ok
+		}
+		return m.loadMatches(match, func() { m.jumpToMatch(1, true) })
+	}
+	p.onCancel = func() {
+		m.search.query = ""
+		m.cursor = m.search.origin
+		m.adjustViewportToCursor()
+		m.status = ""
+	}
+	p.onKey = func(key string) bool {
+		if key != "tab" {
+			return false
+		}
+		m.search.deep = !m.search.deep
+		return true
+	}
+	m.openPrompt(p)
+}
+
+// searchLabel shows whether the search reaches into unloaded directories
+func (m *Model) searchLabel() string {
+	if m.search.deep {
+		return "/ (deep) "
+	}
+	return "/"
+}
+
+// matchesSearch reports whether a node name matches the query using smart case
+func (m *Model) matchesSearch(node *tree.Node) bool {
+	_, ok := m.searchMatchRange(node.Name)
+	return ok
+}
+
+// searchMatchRange locates the query inside name; uppercase queries are case-sensitive
+// and a trailing line number is left for the editor
+func (m *Model) searchMatchRange(name string) ([2]int, bool) {
+	return matchRange(m.search.query, name)
+}
+
+// matchRange is searchMatchRange for the given query
+func matchRange(query, name string) ([2]int, bool) {
+	query, _ = splitLineSuffix(query) // "main.go:42" matches main.go
+	if query == "" {
+		return [2]int{}, false
+	}
+	if strings.ToLower(query) != query {
+		i := strings.Index(name, query)
+		if i < 0 {
+			return [2]int{}, false
+		}
+		return [2]int{i, i + len(query)}, true
+	}
+	for start := range name {
+		if end, ok := foldPrefix(name[start:], query); ok {
+			return [2]int{start, start + end}, true
+		}
+	}
+	return [2]int{}, false
+}
+
+// foldPrefix reports whether s starts with the lower case query, folding s
+// rune by rune so the returned length is in bytes of s
+func foldPrefix(s, query string) (int, bool) {
+	i := 0
+	for _, q := range query {
+		if i >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.ToLower(r) != q {
+			return 0, false
+		}
+		i += size
+	}
+	return i, true
+}
+
+// searchMatches returns the indexes of matching nodes in the flattened view
+func (m *Model) searchMatches() []int {
+	var matches []int
+	for i, node := range m.flattenedNodes {
+		if m.matchesSearch(node) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// jumpToMatch moves the cursor to the next (1) or previous (-1) match, wrapping around.
+// With inclusive set, a match under the cursor counts as the next one.
+func (m *Model) jumpToMatch(direction int, inclusive bool) {
+	matches := m.searchMatches()
+	if len(matches) == 0 {
+		if m.search.query != "" {
+			m.SetStatus(fmt.Sprintf("Pattern not found: %s", m.search.query))
+		}
+		m.adjustViewportToCursor()
+		return
+	}
+
+	target := -1
+	if direction > 0 {
+		for i, index := range matches {
+			if index > m.cursor || (inclusive && index == m.cursor) {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			target = 0
+		}
+	} else {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < m.cursor {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			target = len(matches) - 1
+		}
+	}
+
+	m.cursor = matches[target]
+	m.adjustViewportToCursor()
+	m.setInfo(fmt.Sprintf("match %d of %d", target+1, len(matches)))
+}
+
+// deepWalk is a deep search or filter looking through unopened directories in the background
+type deepWalk struct {
+	cancel context.CancelFunc
+	done   func() // Runs once the matches are part of the view
+}
+
+// deepMatchMsg delivers the matches found by a deep walk
+type deepMatchMsg struct {
+	walk      *deepWalk
+	paths     []string
+	truncated bool // The walk stopped at one of its limits
+}
+
+// loadMatches walks the directories below the root in the background for
+// entries that match, given their name and slash-separated path relative to
+// the root. The ancestors of the matches are then expanded so they become part
+// of the flattened view, and done is called.
+func (m *Model) loadMatches(match func(name, rel string) bool, done func()) tea.Cmd {
+	m.cancelDeepWalk()
+	ctx, cancel := context.WithCancel(context.Background())
+	walk := &deepWalk{cancel: cancel, done: done}
+	m.deep = walk
+	m.setInfo("Searching unopened directories… (Esc to cancel)")
+
+	root, opts := m.tree.Path, *m.tree.Options() // Snapshot the settings before leaving the UI goroutine
+	return func() tea.Msg {
+		msg := deepMatchMsg{walk: walk}
+		visited := 0
+		tree.Walk(root, &opts, func(path string, isDir bool) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err == nil && match(filepath.Base(path), filepath.ToSlash(rel)) {
+				msg.paths = append(msg.paths, path)
+			}
+			visited++
+			if visited >= maxDeepSearchNodes || len(msg.paths) >= maxDeepMatches {
+				msg.truncated = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		return msg
+	}
+}
+
+// handleDeepMatches reveals the matches of the current deep walk
+func (m *Model) handleDeepMatches(msg deepMatchMsg) {
+	if m.deep != msg.walk {
+		return // Stale result from a cancelled walk
+	}
+	m.deep = nil
+	current := m.currentNode()
+	for _, path := range msg.paths {
+		m.expandTo(path)
+	}
+	m.updateFlattenedNodes()
+	m.restoreCursor(current)
+	msg.walk.done()
+	if msg.truncated {
+		m.status = fmt.Sprintf("%s (search limit reached, some matches may be missing)", m.status)
+	}
+}
+
+// cancelDeepWalk stops a deep search or filter in progress, reporting whether there was one
+func (m *Model) cancelDeepWalk() bool {
+	if m.deep == nil {
+		return false
+	}
+	m.deep.cancel()
+	m.deep = nil
+	return true
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"dtree/internal/tree"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortMenuKeys maps the keys of the sort menu to sort keys
+var sortMenuKeys = map[string]tree.SortKey{
+	"n": tree.SortName,
+	"v": tree.SortNatural,
+	"i": tree.SortCaseInsensitive,
+	"s": tree.SortSize,
+	"t": tree.SortTime,
+	"e": tree.SortExtension,
+}
+
+// openSortMenu asks for a sort key or modifier with a single key press
+func (m *Model) openSortMenu() {
+	p := &prompt{
+		label:    "Sort: [n]ame [v]ersion [i]gnore-case [s]ize [t]ime [e]xtension [d]irs-first [r]everse ",
+		menu:     true,
+		onSubmit: func(string) tea.Cmd { return nil },
+	}
+	p.onKey = func(key string) bool {
+		order := m.tree.Options().Sort
+		switch key {
+		case "d":
+			order.DirsFirst = !order.DirsFirst
+		case "r":
+			order.Reverse = !order.Reverse
+		default:
+			sortKey, ok := sortMenuKeys[key]
+			if !ok {
+				return false
+			}
+			order.Key = sortKey
+		}
+		m.applySort(order)
+		m.setInfo(fmt.Sprintf("Sorted by %s", order))
+		return true
+	}
+	m.openPrompt(p)
+}
+
+// reverseSort flips the current sort order
+func (m *Model) reverseSort() {
+	order := m.tree.Options().Sort
+	order.Reverse = !order.Reverse
+	m.applySort(order)
+	m.setInfo(fmt.Sprintf("Sorted by %s", order))
+}
+
+// applySort re-sorts every loaded directory, keeping the cursor on the same node
+func (m *Model) applySort(order tree.SortOrder) {
+	current := m.currentNode()
+	m.tree.Options().Sort = order
+	m.tree.Sort()
+	m.updateFlattenedNodes()
+	m.restoreCursor(current)
+}
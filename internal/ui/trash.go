@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"dtree/internal/fileops"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// trashView lists trashed entries so they can be restored, opened with T
+type trashView struct {
+	items    []fileops.TrashItem
+	selected int
+}
+
+// homeTrash locates the user's trash, reporting failures in the status line
+func (m *Model) homeTrash() (fileops.Trash, bool) {
+	trash, err := fileops.HomeTrash()
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Cannot find the trash: %v", err))
+		return fileops.Trash{}, false
+	}
+	return trash, true
+}
+
+// confirmTrash asks before moving the node under the cursor to the trash, like the bulk action does
+func (m *Model) confirmTrash() {
+	node := m.editableNode("trash")
+	if node == nil {
+		return
+	}
+
+	question := fmt.Sprintf("Move %s to the trash? [y/N] ", node.Name)
+	if node.IsDir {
+		question = fmt.Sprintf("Move directory %s and everything in it to the trash? [y/N] ", node.Name)
+	}
+	m.confirm(question, func() {
+		trash, ok := m.homeTrash()
+		if !ok {
+			return
+		}
+		m.cancelLoads(node)
+		item, err := trash.Put(node.Path)
+		if err != nil {
+			m.SetStatus(fmt.Sprintf("Cannot move %s to the trash: %v", node.Name, err))
+			return
+		}
+		m.removeNode(node)
+		m.setInfo(fmt.Sprintf("Moved %s to the trash (u to undo)", m.displayPath(node.Path)))
+		m.record(fileops.TrashOperation(trash, item))
+	})
+}
+
+// openTrash shows the trash view
+func (m *Model) openTrash() {
+	trash, ok := m.homeTrash()
+	if !ok {
+		return
+	}
+	items, err := trash.List()
+	if err != nil {
+		m.SetStatus(fmt.Sprintf("Cannot read the trash: %v", err))
+		return
+	}
+	m.trash = &trashView{items: items}
+	if len(items) == 0 {
+		m.setInfo("The trash is empty")
+	}
+}
+
+// updateTrash handles keys while the trash view is open
+func (m *Model) updateTrash(msg tea.KeyMsg) tea.Cmd {
+	v := m.trash
+	switch msg.String() {
+	case "esc", "q", "T":
+		m.trash = nil
+	case "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		if v.selected > 0 {
+			v.selected--
+		}
+	case "down", "j":
+		if v.selected < len(v.items)-1 {
+			v.selected++
+		}
+	case "enter", "r":
+		if v.selected < len(v.items) {
+			m.restoreTrashed(v.selected)
+		}
+	case "D":
+		if v.selected < len(v.items) {
+			m.confirmPurge(v.selected)
+		}
+	}
+	return nil
+}
+
+// restoreTrashed puts a trashed item back and shows it in the tree
+func (m *Model) restoreTrashed(index int) {
+	trash, ok := m.homeTrash()
+	if !ok {
+		return
+	}
+	item := m.trash.items[index]
+	if err := trash.Restore(item); err != nil {
+		m.SetStatus(fmt.Sprintf("Cannot restore %s: %v", item.Original, err))
+		return
+	}
+	m.removeTrashItem(index)
+	m.revealCreated(item.Original)
+	m.setInfo(fmt.Sprintf("Restored %s", m.displayPath(item.Original)))
+	m.forgetTrashed(trash, item)
+}
+
+// confirmPurge asks before deleting a trashed item for good
+func (m *Model) confirmPurge(index int) {
+	item := m.trash.items[index]
+	m.confirm(fmt.Sprintf("Delete %s from the trash permanently? [y/N] ", item.Name), func() {
+		trash, ok := m.homeTrash()
+		if !ok {
+			return
+		}
+		if err := trash.Purge(item); err != nil {
+			m.SetStatus(fmt.Sprintf("Cannot delete %s: %v", item.Name, err))
+			return
+		}
+		m.removeTrashItem(index)
+		m.setInfo(fmt.Sprintf("Deleted %s permanently", item.Name))
+		m.forgetTrashed(trash, item)
+	})
+}
+
+// removeTrashItem drops an item from the list, keeping the selection in range
+func (m *Model) removeTrashItem(index int) {
+	v := m.trash
+	v.items = append(v.items[:index], v.items[index+1:]...)
+	if v.selected >= len(v.items) && v.selected > 0 {
+		v.selected--
+	}
+}
+
+// renderTrash draws the trash view in place of the tree
+func (m *Model) renderTrash(b *strings.Builder) {
+	v := m.trash
+	b.WriteString(m.headerStyle.Render(fmt.Sprintf("Trash (%d items)", len(v.items))) + "\n")
+
+	rows := m.viewportHeight - 1
+	start := 0
+	if v.selected >= rows {
+		start = v.selected - rows + 1
+	}
+	for i := start; i < len(v.items) && i < start+rows; i++ {
+		item := v.items[i]
+		cursor := " "
+		if i == v.selected {
+			cursor = m.cursorStyle.Render(">")
+		}
+		style := m.fileStyle
+		if item.IsDir {
+			style = m.dirStyle
+		}
+		when := item.DeletedAt.Format("2006-01-02 15:04")
+		b.WriteString(fmt.Sprintf("%s %s  %s\n", cursor, m.ignoredStyle.Render(when), style.Render(m.displayPath(item.Original))))
+	}
+}
@@ -1,13 +1,24 @@
 package ui
 
 import (
-	"dtree/internal/fileops"
+	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Update handles keyboard input and state changes
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if err := m.syncWatches(); err != nil {
+		m.SetStatus(fmt.Sprintf("Not watching for changes: %v", err))
+	}
+	// Keep the preview pane and disk usage in sync with wherever the cursor
+	// ended up, and the git decorations with what changed on disk
+	return model, tea.Batch(cmd, m.previewCmd(), m.duCmd(), m.gitCmd())
+}
+
+// update dispatches a single message
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		// Update terminal dimensions and recalculate viewport
@@ -15,9 +26,52 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.terminalWidth = msg.Width
 		m.updateViewportHeight()
 		m.adjustViewportToCursor()
+	case previewMsg:
+		m.handlePreview(msg)
+	case gitStatusMsg:
+		m.handleGitStatus(msg)
+	case finderBatchMsg:
+		return m, m.handleFinderBatch(msg)
+	case deepMatchMsg:
+		m.handleDeepMatches(msg)
+	case duMsg:
+		return m, m.handleDiskUsage(msg)
+	case loadMsg:
+		return m, m.handleLoad(msg)
+	case spinnerMsg:
+		return m, m.handleSpinner()
+	case watchMsg:
+		return m, m.handleWatch(msg)
+	case execMsg:
+		m.handleExec(msg)
 	case tea.KeyMsg:
+		if m.prompt != nil {
+			return m, m.updatePrompt(msg)
+		}
+		if m.finder != nil {
+			return m, m.updateFinder(msg)
+		}
+		if m.trash != nil {
+			return m, m.updateTrash(msg)
+		}
+
 		switch msg.String() {
-		case "q", "ctrl+c", "esc":
+		case "esc":
+			// Esc stops deep searches and directories that are still loading before it quits
+			if m.cancelDeepWalk() {
+				m.setInfo("Deep search cancelled")
+				return m, nil
+			}
+			if m.cancelLoads(m.tree) > 0 {
+				m.updateFlattenedNodes()
+				m.setInfo("Loading cancelled; press Enter on a directory to retry")
+				return m, nil
+			}
+			if m.clearSelection() {
+				return m, nil
+			}
+			return m, tea.Quit
+		case "q", "ctrl+c":
 			return m, tea.Quit
 		case "up", "k":
 			if m.cursor > 0 {
@@ -49,21 +103,142 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "G":
 			m.jumpToBottom()
 			m.pendingG = false // Reset if was waiting for gg
+		case "ctrl+p":
+			m.pendingG = false
+			return m, m.openFinder()
+		case "/":
+			m.pendingG = false
+			m.startSearch()
+		case "n":
+			m.pendingG = false
+			m.jumpToMatch(1, false)
+		case "N":
+			m.pendingG = false
+			m.jumpToMatch(-1, false)
+		case "p":
+			m.pendingG = false
+			m.togglePreview()
+		case "X":
+			m.pendingG = false
+			m.startExport()
+		case "L":
+			m.pendingG = false
+			m.toggleColumns()
+		case "U":
+			m.pendingG = false
+			m.toggleDiskUsage()
+		case "s":
+			m.pendingG = false
+			m.openSortMenu()
+		case "f":
+			m.pendingG = false
+			m.startFilter()
+		case "S":
+			m.pendingG = false
+			m.reverseSort()
+		case "I":
+			m.pendingG = false
+			m.toggleIgnored()
+		case ".":
+			m.pendingG = false
+			m.toggleHidden()
+		case "M":
+			m.pendingG = false
+			m.toggleChangedOnly()
+		case "a":
+			m.pendingG = false
+			m.startCreate()
+		case "r":
+			m.pendingG = false
+			m.startRename()
+		case "c":
+			m.pendingG = false
+			if len(m.marks) > 0 {
+				m.startBulkTransfer(false)
+			} else {
+				m.startTransfer(false)
+			}
+		case "m":
+			m.pendingG = false
+			if len(m.marks) > 0 {
+				m.startBulkTransfer(true)
+			} else {
+				m.startTransfer(true)
+			}
+		case "d":
+			m.pendingG = false
+			if len(m.marks) > 0 {
+				m.confirmBulkDelete(false)
+			} else {
+				m.confirmTrash()
+			}
+		case "D":
+			m.pendingG = false
+			if len(m.marks) > 0 {
+				m.confirmBulkDelete(true)
+			} else {
+				m.confirmDelete()
+			}
+		case "t":
+			m.pendingG = false
+			m.toggleMark()
+		case "+":
+			m.pendingG = false
+			m.startMarkPattern()
+		case "*":
+			m.pendingG = false
+			m.invertMarks()
+		case "V":
+			m.pendingG = false
+			m.toggleVisual()
+		case "y":
+			m.pendingG = false
+			return m, m.copyPaths()
+		case "o":
+			m.pendingG = false
+			return m, m.openTargets()
+		case "e":
+			m.pendingG = false
+			return m, m.editCurrent()
+		case "T":
+			m.pendingG = false
+			m.openTrash()
+		case "u":
+			m.pendingG = false
+			m.undo()
+		case "ctrl+r":
+			m.pendingG = false
+			m.redo()
+		case "R":
+			m.pendingG = false
+			m.reloadCurrent()
+		case "ctrl+l":
+			m.pendingG = false
+			m.reload()
+			m.setInfo("Reloaded the tree from disk")
 		case "enter", " ":
 			m.pendingG = false // Reset pending g on other actions
 			if m.cursor < len(m.flattenedNodes) {
 				node := m.flattenedNodes[m.cursor]
-				if node.IsDir {
+				if node.Link != nil && node.Link.Loop {
+					m.SetStatus(fmt.Sprintf("Not following recursive symlink: %s -> %s", node.Name, node.Link.Target))
+				} else if node.IsDir && node.Err != nil && !m.isLoading(node) {
+					cmd := m.startLoad(node) // Retry the failed load
+					m.updateFlattenedNodes()
+					return m, cmd
+				} else if node.IsDir {
 					node.IsExpanded = !node.IsExpanded
-					// Lazy load children when expanding
+					// Lazy load children in the background when expanding
+					var cmd tea.Cmd
 					if node.IsExpanded && len(node.Children) == 0 {
-						node.LoadChildren()
+						cmd = m.startLoad(node)
 					}
 					m.updateFlattenedNodes()
 					m.adjustViewportToCursor()
+					return m, cmd
 				} else {
-					// Open file with default application
-					m.openFileWithStatus(node.Path)
+					// Open file with its opener rule or the default application
+					return m, m.openCurrent(node)
 				}
 			}
 		default:
@@ -73,13 +248,3 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	return m, nil
 }
-
-// openFileWithStatus opens a file and updates status accordingly
-func (m *Model) openFileWithStatus(filePath string) {
-	err := fileops.OpenFile(filePath)
-	if err != nil {
-		m.status = fileops.FormatOpenError(filePath, err)
-	} else {
-		m.status = ""
-	}
-}
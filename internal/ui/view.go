@@ -8,13 +8,57 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Key hints shown below the tree and the trash view
+const (
+	treeControls  = "↑↓/jk navigate, Ctrl+U/D half-page, Ctrl+B/F full-page, gg/G top/bottom, Enter/Space expand/collapse, Esc cancel loading, / search, n/N next/prev, Ctrl+P find, f filter, . hidden, I ignored, M changed, R/Ctrl+L reload, a/r/c/m/d/D create/rename/copy/move/trash/delete, t/+/*/V mark/by pattern/invert/range, y copy paths, o open, e edit, T trash, u/Ctrl+R undo/redo, X export, p preview, L columns, U disk usage, s sort, q quit"
+	trashControls = "↑↓/jk select, Enter/r restore, D delete permanently, Esc/T close"
+)
+
 // View renders the TUI display
 func (m *Model) View() string {
 	var b strings.Builder
 
-	header := m.headerStyle.Render(fmt.Sprintf("DTree - %s (initial depth: %d)", m.rootPath, m.initialDepth))
+	title := fmt.Sprintf("DTree - %s (initial depth: %d)", m.rootPath, m.initialDepth)
+	if m.visual {
+		title += " -- VISUAL --"
+	} else if len(m.marks) > 0 {
+		title += fmt.Sprintf(" [%d marked]", len(m.marks))
+	}
+	header := m.headerStyle.Render(title)
 	b.WriteString(header + "\n\n")
 
+	if m.finder != nil {
+		m.renderFinder(&b)
+		return b.String()
+	}
+
+	controls := treeControls
+	if m.trash != nil {
+		m.renderTrash(&b)
+		controls = trashControls
+	} else {
+		m.renderTree(&b)
+	}
+
+	if m.prompt != nil {
+		b.WriteString("\n" + m.renderPrompt())
+	}
+
+	b.WriteString(lipgloss.NewStyle().Render("\nControls: " + controls))
+
+	if m.status != "" {
+		statusStyle := m.errorStyle
+		if m.statusIsInfo {
+			statusStyle = m.infoStyle
+		}
+		b.WriteString(statusStyle.Render("\n" + m.status))
+	}
+
+	return b.String()
+}
+
+// renderTree draws the visible part of the tree, with columns and the preview pane
+func (m *Model) renderTree(b *strings.Builder) {
 	// Calculate visible range for viewport
 	start := m.viewportOffset
 	end := start + m.viewportHeight
@@ -29,21 +73,18 @@ func (m *Model) View() string {
 	}
 
 	// Render visible nodes
+	lines := make([]string, 0, end-start)
 	for i := start; i < end; i++ {
 		node := m.flattenedNodes[i]
-		line := m.renderTreeLine(i, node)
-		b.WriteString(line + "\n")
+		lines = append(lines, m.renderTreeLine(i, node))
 	}
-
-	controls := lipgloss.NewStyle().Render("\nControls: ↑↓/jk navigate, Ctrl+U/D half-page, Ctrl+B/F full-page, gg/G top/bottom, Enter/Space expand/collapse, q quit")
-	b.WriteString(controls)
-
-	if m.status != "" {
-		status := m.errorStyle.Render("\n" + m.status)
-		b.WriteString(status)
+	lines = m.addColumns(lines, m.flattenedNodes[start:end])
+	if m.showPreview {
+		lines = m.joinPreview(lines)
+	}
+	for _, line := range lines {
+		b.WriteString(line + "\n")
 	}
-
-	return b.String()
 }
 
 // renderTreeLine formats a single tree node with styling and tree characters
@@ -53,6 +94,11 @@ func (m *Model) renderTreeLine(index int, node *tree.Node) string {
 		cursor = m.cursorStyle.Render(">")
 	}
 
+	mark := " "
+	if m.isMarked(index, node) {
+		mark = m.markStyle.Render("*")
+	}
+
 	treeChars := m.getTreeChars(node)
 
 	var nameStyle lipgloss.Style
@@ -60,19 +106,72 @@ func (m *Model) renderTreeLine(index int, node *tree.Node) string {
 
 	if node.IsDir {
 		nameStyle = m.dirStyle
+		if node.Ignored {
+			nameStyle = m.ignoredStyle.Bold(true)
+		}
 		var indicator string
 		if node.IsExpanded {
 			indicator = "▼ "
 		} else {
 			indicator = "▶ "
 		}
-		name = nameStyle.Render(indicator + node.Name)
+		name = nameStyle.Render(indicator) + m.renderName(node, m.linkNameStyle(node, nameStyle))
 	} else {
 		nameStyle = m.fileStyle
-		name = nameStyle.Render(node.Name)
+		if node.Ignored {
+			nameStyle = m.ignoredStyle
+		}
+		name = m.renderName(node, m.linkNameStyle(node, nameStyle))
+	}
+
+	return fmt.Sprintf("%s%s%s%s%s%s%s%s", cursor, mark, treeChars, name, m.linkSuffix(node), m.loadingSuffix(node), m.errorSuffix(node), m.gitMarker(node))
+}
+
+// errorSuffix renders why a directory could not be read, e.g. " [permission denied]"
+func (m *Model) errorSuffix(node *tree.Node) string {
+	if node.Err == nil {
+		return ""
+	}
+	return m.errorStyle.Render(" [" + node.ErrorSummary() + "]")
+}
+
+// linkNameStyle colors symlink names, using the error color for broken links
+func (m *Model) linkNameStyle(node *tree.Node, style lipgloss.Style) lipgloss.Style {
+	switch {
+	case node.Link == nil:
+		return style
+	case node.Link.Broken:
+		return m.errorStyle
+	default:
+		return m.linkStyle
 	}
+}
 
-	return fmt.Sprintf("%s %s%s", cursor, treeChars, name)
+// linkSuffix renders " -> target" for symlinks, noting broken and looping links
+func (m *Model) linkSuffix(node *tree.Node) string {
+	link := node.Link
+	if link == nil {
+		return ""
+	}
+	switch {
+	case link.Broken:
+		return m.errorStyle.Render(" -> " + link.Target + " [broken]")
+	case link.Loop:
+		return m.ignoredStyle.Render(" -> " + link.Target + " [recursive, not followed]")
+	default:
+		return m.ignoredStyle.Render(" -> ") + m.linkStyle.Render(link.Target)
+	}
+}
+
+// renderName styles a node name, highlighting the part matching the active search
+func (m *Model) renderName(node *tree.Node, style lipgloss.Style) string {
+	span, ok := m.searchMatchRange(node.Name)
+	if !ok {
+		return style.Render(node.Name)
+	}
+	return style.Render(node.Name[:span[0]]) +
+		m.matchStyle.Render(node.Name[span[0]:span[1]]) +
+		style.Render(node.Name[span[1]:])
 }
 
 // getTreeChars generates proper tree connecting characters (├──, └──, │)
@@ -87,23 +186,23 @@ func (m *Model) getTreeChars(node *tree.Node) string {
 	positions := make([]bool, node.Depth)
 
 	for current.Parent != nil {
-		isLast := current.IsLastChild()
+		isLast := m.isLastVisible(current)
 		positions[current.Depth-1] = !isLast
 		current = current.Parent
 	}
 
 	for i := 0; i < node.Depth-1; i++ {
 		if positions[i] {
-			result.WriteString("│   ")
+			result.WriteString(tree.PipeIndent)
 		} else {
-			result.WriteString("    ")
+			result.WriteString(tree.BlankIndent)
 		}
 	}
 
-	if node.IsLastChild() {
-		result.WriteString("└── ")
+	if m.isLastVisible(node) {
+		result.WriteString(tree.LastPrefix)
 	} else {
-		result.WriteString("├── ")
+		result.WriteString(tree.BranchPrefix)
 	}
 
 	return result.String()
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/watch"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchMsg delivers directories that changed on disk; nil dirs means the watcher stopped
+type watchMsg struct {
+	events <-chan []string
+	dirs   []string
+}
+
+// SetWatch starts or stops refreshing expanded directories when they change on disk
+func (m *Model) SetWatch(enabled bool) error {
+	if !enabled {
+		if m.watcher != nil {
+			m.watcher.Close()
+			m.watcher = nil
+		}
+		return nil
+	}
+	if m.watcher != nil {
+		return nil
+	}
+
+	watcher, err := watch.New()
+	if err != nil {
+		return err
+	}
+	m.watcher = watcher
+	m.watchStale = false
+	return m.watcher.Watch(m.expandedDirs)
+}
+
+// trackExpanded records the expanded directories in the view, flagging the
+// watches for an update when the set changed
+func (m *Model) trackExpanded() {
+	var dirs []string
+	for _, node := range m.flattenedNodes {
+		if node.IsDir && node.IsExpanded {
+			dirs = append(dirs, node.Path)
+		}
+	}
+	if !slices.Equal(dirs, m.expandedDirs) {
+		m.expandedDirs = dirs
+		m.watchStale = true
+	}
+}
+
+// syncWatches watches exactly the expanded directories in the view, once
+// after each change to them
+func (m *Model) syncWatches() error {
+	if m.watcher == nil || !m.watchStale {
+		return nil
+	}
+	m.watchStale = false
+	return m.watcher.Watch(m.expandedDirs)
+}
+
+// watchCmd waits for the next change, or returns nil when not watching
+func (m *Model) watchCmd() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return waitForWatch(m.watcher.Events())
+}
+
+// waitForWatch receives the next batch of changed directories
+func waitForWatch(events <-chan []string) tea.Cmd {
+	return func() tea.Msg {
+		return watchMsg{events: events, dirs: <-events}
+	}
+}
+
+// handleWatch re-reads the entries of the changed directories, keeping
+// expansion state and the cursor, and keeps listening for more changes
+func (m *Model) handleWatch(msg watchMsg) tea.Cmd {
+	if m.watcher == nil || msg.events != m.watcher.Events() || msg.dirs == nil {
+		return nil // Stale message from a stopped watcher
+	}
+
+	previous, cursor := m.flattenedNodes, m.cursor
+	refreshed := false
+	for _, dir := range msg.dirs {
+		node := m.tree.Find(dir)
+		if node == nil || !node.IsDir || m.isLoading(node) {
+			continue // A directory still being listed picks up the change itself
+		}
+		before := node.Children
+		node.Refresh()
+		m.cancelRemoved(before, node)
+		refreshed = true
+	}
+	if refreshed {
+		m.refreshGitStatus()
+		m.updateFlattenedNodes()
+		m.restoreCursorNear(previous, cursor)
+		m.previewPath = "" // The previewed file may have been rewritten
+	}
+	return m.watchCmd()
+}
+
+// cancelRemoved stops the listings below directories a refresh dropped from parent
+func (m *Model) cancelRemoved(before []*tree.Node, parent *tree.Node) {
+	kept := make(map[*tree.Node]bool, len(parent.Children))
+	for _, child := range parent.Children {
+		kept[child] = true
+	}
+	for _, child := range before {
+		if child.IsDir && !kept[child] {
+			m.cancelLoads(child)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// debounce is how long changes are collected before they are reported together,
+// so a build writing hundreds of files causes one refresh instead of hundreds
+const debounce = 100 * time.Millisecond
+
+// Watcher reports directories whose entries were created, deleted, renamed or
+// written. It uses inotify on Linux and polls directory times elsewhere.
+type Watcher struct {
+	events  chan []string
+	changes chan string // Directories reported by the backend, before debouncing
+	done    chan struct{}
+	backend backend
+
+	mu      sync.Mutex
+	watched map[string]bool // Directories passed to Watch; false when adding the watch failed
+}
+
+// backend is the platform-specific source of directory changes
+type backend interface {
+	add(dir string) error
+	remove(dir string)
+	close() error
+}
+
+// New starts a watcher with no directories
+func New() (*Watcher, error) {
+	w := &Watcher{
+		events:  make(chan []string),
+		changes: make(chan string, 64),
+		done:    make(chan struct{}),
+		watched: make(map[string]bool),
+	}
+	b, err := newBackend(w.changes, w.done)
+	if err != nil {
+		return nil, err
+	}
+	w.backend = b
+	go w.collect()
+	return w, nil
+}
+
+// Events delivers sorted batches of changed directories; it is closed by Close
+func (w *Watcher) Events() <-chan []string {
+	return w.events
+}
+
+// Watch makes dirs the set of watched directories, adding and removing
+// watches as needed. It returns the first error for a newly added directory;
+// directories that failed are not retried until they are dropped and passed again.
+func (w *Watcher) Watch(dirs []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wanted := make(map[string]bool, len(dirs))
+	var firstErr error
+	for _, dir := range dirs {
+		wanted[dir] = true
+		if _, ok := w.watched[dir]; ok {
+			continue
+		}
+		err := w.backend.add(dir)
+		w.watched[dir] = err == nil
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for dir, ok := range w.watched {
+		if !wanted[dir] {
+			if ok {
+				w.backend.remove(dir)
+			}
+			delete(w.watched, dir)
+		}
+	}
+	return firstErr
+}
+
+// Close removes every watch and stops the watcher
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.backend.close()
+}
+
+// collect debounces raw changes into batches, merging batches the reader has not taken yet
+func (w *Watcher) collect() {
+	defer close(w.events)
+
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+	var out chan []string // nil while there is nothing to deliver
+	var batch []string
+	for {
+		select {
+		case dir := <-w.changes:
+			pending[dir] = true
+			if timer == nil {
+				timer = time.After(debounce)
+			}
+		case <-timer:
+			timer = nil
+			for _, dir := range batch {
+				pending[dir] = true
+			}
+			batch = sorted(pending)
+			pending = make(map[string]bool)
+			out = w.events
+		case out <- batch:
+			batch, out = nil, nil
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// sorted returns the directories in name order
+func sorted(dirs map[string]bool) []string {
+	result := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		result = append(result, dir)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,111 @@
+package watch
+
+import (
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the events that change what a directory listing shows
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_CLOSE_WRITE | unix.IN_ATTRIB | unix.IN_ONLYDIR
+
+// inotify watches directories with one inotify instance
+type inotify struct {
+	file *os.File // Non-blocking, so Close interrupts a pending Read
+
+	mu   sync.Mutex
+	dirs map[int]string // Watch descriptor -> directory
+	wds  map[string]int
+}
+
+func newBackend(changes chan<- string, done <-chan struct{}) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	b := &inotify{
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int]string),
+		wds:  make(map[string]int),
+	}
+	go b.read(changes, done)
+	return b, nil
+}
+
+func (b *inotify) add(dir string) error {
+	wd, err := unix.InotifyAddWatch(int(b.file.Fd()), dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "watch", Path: dir, Err: err}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dirs[wd] = dir
+	b.wds[dir] = wd
+	return nil
+}
+
+func (b *inotify) remove(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wd, ok := b.wds[dir]; ok {
+		unix.InotifyRmWatch(int(b.file.Fd()), uint32(wd))
+		delete(b.wds, dir)
+		delete(b.dirs, wd)
+	}
+}
+
+func (b *inotify) close() error {
+	return b.file.Close()
+}
+
+// read turns inotify events into changed directories until the file is closed
+func (b *inotify) read(changes chan<- string, done <-chan struct{}) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			for _, dir := range b.changedDirs(event) {
+				select {
+				case changes <- dir:
+				case <-done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// changedDirs maps an event to the directories to refresh; after a queue
+// overflow events were lost, so every watched directory is refreshed
+func (b *inotify) changedDirs(event *unix.InotifyEvent) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+		dirs := make([]string, 0, len(b.wds))
+		for dir := range b.wds {
+			dirs = append(dirs, dir)
+		}
+		return dirs
+	}
+	dir, ok := b.dirs[int(event.Wd)]
+	if !ok {
+		return nil
+	}
+	if event.Mask&unix.IN_IGNORED != 0 {
+		// The directory itself was deleted; its parent reports that
+		delete(b.dirs, int(event.Wd))
+		delete(b.wds, dir)
+		return nil
+	}
+	return []string{dir}
+}
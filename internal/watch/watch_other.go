@@ -0,0 +1,88 @@
+//go:build !linux
+
+package watch
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often directory modification times are compared
+const pollInterval = time.Second
+
+// poller detects changes by polling directory modification times, which
+// change whenever an entry is created, deleted or renamed
+type poller struct {
+	stop chan struct{}
+
+	mu   sync.Mutex
+	dirs map[string]time.Time // Directory -> modification time when last seen
+}
+
+func newBackend(changes chan<- string, done <-chan struct{}) (backend, error) {
+	b := &poller{stop: make(chan struct{}), dirs: make(map[string]time.Time)}
+	go b.poll(changes, done)
+	return b, nil
+}
+
+func (b *poller) add(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dirs[dir] = info.ModTime()
+	return nil
+}
+
+func (b *poller) remove(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.dirs, dir)
+}
+
+func (b *poller) close() error {
+	close(b.stop)
+	return nil
+}
+
+// poll reports directories whose modification time moved since the last tick
+func (b *poller) poll(changes chan<- string, done <-chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+
+		for _, dir := range b.changedDirs() {
+			select {
+			case changes <- dir:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// changedDirs stats every watched directory, recording the new times
+func (b *poller) changedDirs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var changed []string
+	for dir, seen := range b.dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(seen) {
+			changed = append(changed, dir)
+			if err == nil {
+				b.dirs[dir] = info.ModTime()
+			}
+		}
+	}
+	return changed
+}
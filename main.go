@@ -1,22 +1,78 @@
 package main
 
 import (
+	"dtree/internal/columns"
+	"dtree/internal/export"
+	"dtree/internal/fileops"
 	"dtree/internal/tree"
 	"dtree/internal/ui"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// patternList collects a repeatable pattern flag
+type patternList []string
+
+func (p *patternList) String() string { return strings.Join(*p, " ") }
+
+func (p *patternList) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
 func main() {
 	var initialDepth int
 	var rootPath string
 	var showHelp bool
+	var gitignore bool
+	var showAll bool
+	var followLinks bool
+	var includePatterns, excludePatterns patternList
+	var printMode bool
+	var noReport bool
+	var formatName string
+	var jsonOutput bool
+	var xmlOutput bool
+	var showPreview bool
+	var diskUsage bool
+	var watchChanges bool
+	var sortName string
+	var dirsFirst, reverseSort, versionSort, timeSort bool
+	var showPerms, showOwner, showGroup, showSize, showTime, showInodes, showLinks bool
 
 	flag.IntVar(&initialDepth, "d", 1, "Initial depth to expand")
 	flag.IntVar(&initialDepth, "depth", 1, "Initial depth to expand")
+	flag.BoolVar(&showAll, "a", false, "Show hidden files")
+	flag.BoolVar(&showAll, "all", false, "Show hidden files")
+	flag.Var(&includePatterns, "P", "List only files matching the pattern (repeatable)")
+	flag.Var(&excludePatterns, "I", "Do not list entries matching the pattern (repeatable)")
+	flag.BoolVar(&followLinks, "l", false, "Follow symlinks to directories")
+	flag.BoolVar(&followLinks, "follow", false, "Follow symlinks to directories")
+	flag.BoolVar(&gitignore, "gitignore", true, "Hide entries matched by .gitignore, .dtreeignore and git excludes")
+	flag.BoolVar(&showPreview, "preview", false, "Start with the file preview pane open")
+	flag.BoolVar(&diskUsage, "du", false, "Start in disk-usage mode")
+	flag.BoolVar(&watchChanges, "watch", true, "Refresh expanded directories when they change on disk")
+	flag.StringVar(&sortName, "sort", "name", "Sort by name, version, iname, size, mtime or extension")
+	flag.BoolVar(&dirsFirst, "dirsfirst", false, "List directories before files")
+	flag.BoolVar(&reverseSort, "r", false, "Reverse the sort order")
+	flag.BoolVar(&versionSort, "v", false, "Sort by version (same as --sort version)")
+	flag.BoolVar(&timeSort, "t", false, "Sort by modification time (same as --sort mtime)")
+	flag.BoolVar(&printMode, "print", false, "Print the tree and exit instead of starting the TUI")
+	flag.StringVar(&formatName, "format", "text", "Print format: text, json, yaml or xml")
+	flag.BoolVar(&jsonOutput, "J", false, "Print as JSON (same as --format json)")
+	flag.BoolVar(&xmlOutput, "X", false, "Print as XML (same as --format xml)")
+	flag.BoolVar(&showPerms, "p", false, "Show permissions")
+	flag.BoolVar(&showOwner, "u", false, "Show file owner")
+	flag.BoolVar(&showGroup, "g", false, "Show file group")
+	flag.BoolVar(&showSize, "s", false, "Show file size")
+	flag.BoolVar(&showTime, "D", false, "Show modification time")
+	flag.BoolVar(&showInodes, "inodes", false, "Show inode numbers")
+	flag.BoolVar(&showLinks, "links", false, "Show hard link counts")
+	flag.BoolVar(&noReport, "noreport", false, "Omit the file and directory report in print mode")
 	flag.BoolVar(&showHelp, "h", false, "Show help message")
 	flag.BoolVar(&showHelp, "help", false, "Show help message")
 	flag.Parse()
@@ -27,27 +83,111 @@ func main() {
 		fmt.Println("  dtree [options] [directory]")
 		fmt.Println("\nOptions:")
 		fmt.Println("  -d, --depth <num>   Initial depth to expand (default: 1)")
+		fmt.Println("  -a, --all           Show hidden files (default: hidden)")
+		fmt.Println("  -P <pattern>        List only files matching the pattern (repeatable)")
+		fmt.Println("  -I <pattern>        Do not list entries matching the pattern (repeatable)")
+		fmt.Println("                      Globs by default, a|b alternatives, re:<regex>, !negation")
+		fmt.Println("  -l, --follow        Follow symlinks to directories (loops are detected)")
+		fmt.Println("  --gitignore=false   Show entries matched by ignore files (default: hidden)")
+		fmt.Println("  --preview           Start with the preview pane open")
+		fmt.Println("  --du                Start in disk-usage mode")
+		fmt.Println("  --watch=false       Do not refresh the tree when files change (default: watch)")
+		fmt.Println("  --sort <key>        Sort by name, version, iname, size, mtime or extension")
+		fmt.Println("  -v, -t              Sort by version or modification time")
+		fmt.Println("  --dirsfirst, -r     List directories first, reverse the order")
+		fmt.Println("  --print             Print the tree and exit (automatic when piped)")
+		fmt.Println("  --format <fmt>      Print as text, json, yaml or xml (implies --print)")
+		fmt.Println("  -J, -X              Print as JSON or XML, like tree -J / tree -X")
+		fmt.Println("  --noreport          Omit the directory/file counts in print mode")
+		fmt.Println("  -p, -u, -g, -s, -D  Show permissions, owner, group, size, modification time")
+		fmt.Println("  --inodes, --links   Show inode numbers and hard link counts")
 		fmt.Println("  -h, --help          Show this help message")
 		fmt.Println("\nControls:")
 		fmt.Println("  ↑/↓ or j/k          Navigate up/down")
 		fmt.Println("  Ctrl+U/D            Jump half-screen up/down")
 		fmt.Println("  Ctrl+B/F            Jump full-screen up/down")
 		fmt.Println("  gg/G                Go to top/bottom")
-		fmt.Println("  Enter/Space         Expand/collapse directories")
-		fmt.Println("  Enter               Open files with default application")
-		fmt.Println("  q/Ctrl+C/Esc        Quit")
+		fmt.Println("  Enter/Space         Expand/collapse directories (retries unreadable ones)")
+		fmt.Println("  Enter               Open files (rules in ~/.config/dtree/openers, else the default app)")
+		fmt.Println("  e                   Edit the file in $VISUAL/$EDITOR, at the line of a file:42 search")
+		fmt.Println("  Ctrl+P              Fuzzy-find any file below the root (Ctrl+E or file:42 edits it)")
+		fmt.Println("  /                   Search names (Tab in prompt searches unloaded dirs)")
+		fmt.Println("  n/N                 Jump to next/previous match")
+		fmt.Println("  .                   Show/hide hidden files")
+		fmt.Println("  I                   Show/hide ignored entries")
+		fmt.Println("  M                   Show only files changed in git")
+		fmt.Println("  R / Ctrl+L          Reload the directory under the cursor / the whole tree")
+		fmt.Println("  a                   Create a file (end the name with / for a directory)")
+		fmt.Println("  r                   Rename the entry under the cursor")
+		fmt.Println("  c / m               Copy / move the entry under the cursor, or the marked entries")
+		fmt.Println("  d                   Move the entry under the cursor, or the marked entries, to the trash, after confirming")
+		fmt.Println("  D                   Delete the entry under the cursor, or the marked ones, permanently (asks first)")
+		fmt.Println("  T                   Show the trash to restore deleted entries")
+		fmt.Println("  t                   Mark/unmark the entry under the cursor")
+		fmt.Println("  + / *               Mark entries matching patterns / invert the marks")
+		fmt.Println("  V                   Start a range selection; V again marks it")
+		fmt.Println("  y                   Copy marked paths (or the cursor's) to the clipboard")
+		fmt.Println("  o                   Open marked entries (or the cursor's) with default applications")
+		fmt.Println("  u / Ctrl+R          Undo / redo the last file operation (kept across restarts)")
+		fmt.Println("  X                   Export the expanded view to a file")
+		fmt.Println("  p                   Toggle the preview pane")
+		fmt.Println("  L                   Toggle metadata columns (permissions, owner, size, time)")
+		fmt.Println("  U                   Toggle disk-usage mode (directory sizes, largest first)")
+		fmt.Println("  s / S               Choose the sort order / reverse it")
+		fmt.Println("  f                   Filter the view by patterns (matches and their parents)")
+		fmt.Println("  Esc                 Cancel searching or loading, then the selection, otherwise quit")
+		fmt.Println("  q/Ctrl+C            Quit")
 		fmt.Println("\nExamples:")
 		fmt.Println("  dtree               # View current directory")
 		fmt.Println("  dtree /home/user    # View specific directory")
 		fmt.Println("  dtree -d 3 .        # Expand 3 levels deep")
+		fmt.Println("  dtree -d 2 | less   # Print 2 levels like tree")
+		fmt.Println("  dtree -J -d 3 .     # Snapshot 3 levels as JSON")
+		fmt.Println("  dtree -p -s -D --print  # List with metadata like tree -psD")
+		fmt.Println("  dtree -P '*.proto'  # Only .proto files")
+		fmt.Println("  dtree -I '*_test.go|testdata'  # Everything except tests")
 		os.Exit(0)
 	}
 
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		format = export.FormatJSON
+	}
+	if xmlOutput {
+		format = export.FormatXML
+	}
+
+	sortKey, err := tree.ParseSortKey(sortName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if versionSort {
+		sortKey = tree.SortNatural
+	}
+	if timeSort {
+		sortKey = tree.SortTime
+	}
+
+	include, err := tree.ParsePatterns(includePatterns)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	exclude, err := tree.ParsePatterns(excludePatterns)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	args := flag.Args()
 	if len(args) > 0 {
 		rootPath = args[0]
 	} else {
-		var err error
 		rootPath, err = os.Getwd()
 		if err != nil {
 			fmt.Printf("Error getting current directory: %v\n", err)
@@ -60,11 +200,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	var columnSet columns.Set
+	for column, enabled := range map[columns.Column]bool{
+		columns.Permissions: showPerms,
+		columns.Owner:       showOwner,
+		columns.Group:       showGroup,
+		columns.Size:        showSize,
+		columns.Time:        showTime,
+		columns.Inode:       showInodes,
+		columns.Links:       showLinks,
+	} {
+		if enabled {
+			columnSet = columnSet.With(column)
+		}
+	}
+
 	// Build the tree structure
-	rootTree := tree.Build(rootPath, initialDepth)
+	opts := &tree.Options{
+		HideIgnored: gitignore,
+		HideHidden:  !showAll,
+		Include:     include,
+		Exclude:     exclude,
+		FollowLinks: followLinks,
+		Sort:        tree.SortOrder{Key: sortKey, DirsFirst: dirsFirst, Reverse: reverseSort},
+	}
+	rootTree := tree.BuildWithOptions(rootPath, initialDepth, opts)
+
+	// Print instead of starting the TUI when asked to or when output is piped
+	if printMode || format != export.FormatText || !isTerminal(os.Stdout) {
+		exportOpts := export.Options{Format: format, Report: !noReport, Columns: columnSet}
+		if err := export.Write(os.Stdout, rootTree, exportOpts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Create the UI model
 	model := ui.New(rootTree, initialDepth, rootPath)
+	model.SetPreview(showPreview)
+	model.SetColumns(columnSet)
+	model.SetDiskUsage(diskUsage)
+	if err := model.SetWatch(watchChanges); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not watching for changes: %v\n", err)
+	}
+	if openersPath, err := fileops.DefaultOpenersPath(); err == nil {
+		openers, err := fileops.LoadOpeners(openersPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring opener rules: %v\n", err)
+		}
+		model.SetOpeners(openers)
+	}
 
 	// Run the TUI
 	p := tea.NewProgram(model)
@@ -73,3 +259,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// isTerminal reports whether the file is attached to a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
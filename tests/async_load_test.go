@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupLargeDir creates a directory with enough entries to be streamed in several batches
+func setupLargeDir(t *testing.T, count int) string {
+	tmpDir := t.TempDir()
+	big := filepath.Join(tmpDir, "big")
+	if err := os.Mkdir(big, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < count; i++ {
+		if err := os.WriteFile(filepath.Join(big, fmt.Sprintf("file%04d.txt", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestStreamChildrenInBatches(t *testing.T) {
+	tmpDir := setupLargeDir(t, 600)
+	root := tree.Build(tmpDir, 1)
+	big := childNames(root)["big"]
+
+	batches := 0
+	for batch := range big.StreamChildren(context.Background()) {
+		if batch.Err != nil {
+			t.Fatal(batch.Err)
+		}
+		big.AddChildren(batch.Children)
+		batches++
+	}
+	if batches < 2 {
+		t.Errorf("600 entries should arrive in several batches, got %d", batches)
+	}
+
+	expected := tree.Build(filepath.Join(tmpDir, "big"), 1)
+	if got, want := strings.Join(childOrder(big), ","), strings.Join(childOrder(expected), ","); got != want {
+		t.Error("streamed children should end up in the same order as a synchronous load")
+	}
+	if big.Children[0].Parent != big || big.Children[0].Depth != 2 {
+		t.Error("streamed children should be attached below the directory")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	received := 0
+	for batch := range big.StreamChildren(ctx) {
+		received += len(batch.Children)
+	}
+	if received == 600 {
+		t.Error("a cancelled stream should stop early")
+	}
+}
+
+func TestUIAsyncLoadAndCancel(t *testing.T) {
+	tmpDir := setupLargeDir(t, 600)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expanding an unloaded directory should return a load command")
+	}
+	if !strings.Contains(cursorLine(model.View()), "loading") {
+		t.Errorf("the directory should show a spinner while loading, got %q", cursorLine(model.View()))
+	}
+
+	// Esc cancels the load instead of quitting
+	if _, quit := model.Update(tea.KeyMsg{Type: tea.KeyEsc}); quit != nil {
+		t.Error("Esc should not quit while a directory is loading")
+	}
+	drainCommands(t, model, cmd)
+	if line := cursorLine(model.View()); !strings.Contains(line, "[load cancelled]") || strings.Contains(line, "loading") {
+		t.Errorf("the cancelled directory should be marked, got %q", line)
+	}
+
+	// Enter retries and loads everything
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+	view := model.View()
+	if strings.Contains(view, "load cancelled") || !strings.Contains(view, "file0599.txt") {
+		t.Error("retrying should load every entry")
+	}
+	if !strings.Contains(cursorLine(view), "big") {
+		t.Errorf("the cursor should stay on the directory while entries stream in, got %q", cursorLine(view))
+	}
+
+	if _, quit := model.Update(tea.KeyMsg{Type: tea.KeyEsc}); quit == nil {
+		t.Error("Esc should quit when nothing is loading")
+	}
+}
+
+func TestParallelBuildMatchesDepth(t *testing.T) {
+	projectDir := setupComplexTestProject(t)
+	root := tree.Build(projectDir, 3)
+
+	var check func(node *tree.Node)
+	check = func(node *tree.Node) {
+		for _, child := range node.Children {
+			if child.Parent != node || child.Depth != node.Depth+1 {
+				t.Errorf("%s has the wrong parent or depth", child.Path)
+			}
+			if child.IsDir && child.Depth < 3 && !child.IsExpanded {
+				t.Errorf("%s should be expanded by the initial load", child.Path)
+			}
+			check(child)
+		}
+	}
+	check(root)
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/columns"
+	"dtree/internal/export"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestNodeMetadata(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 1)
+
+	if !root.Meta.Mode.IsDir() {
+		t.Error("root metadata should be loaded")
+	}
+	for _, child := range root.Children {
+		if child.Name != "file1.txt" {
+			continue
+		}
+		if child.Meta.Size != int64(len("sample content")) {
+			t.Errorf("Size = %d, want %d", child.Meta.Size, len("sample content"))
+		}
+		if child.Meta.Mode.Perm() != 0644 {
+			t.Errorf("Mode = %v, want -rw-r--r--", child.Meta.Mode)
+		}
+		if time.Since(child.Meta.ModTime) > time.Hour {
+			t.Errorf("ModTime should be recent, got %v", child.Meta.ModTime)
+		}
+		if child.Meta.Links == 0 {
+			t.Error("link count should be at least 1")
+		}
+		return
+	}
+	t.Fatal("file1.txt not found")
+}
+
+func TestColumnFormatting(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	times := map[time.Duration]string{
+		10 * time.Second:     "just now",
+		5 * time.Minute:      "5m ago",
+		3 * time.Hour:        "3h ago",
+		2 * 24 * time.Hour:   "2d ago",
+		400 * 24 * time.Hour: "2023-04-28",
+	}
+	for ago, want := range times {
+		if got := columns.RelativeTime(now.Add(-ago), now); got != want {
+			t.Errorf("RelativeTime(-%v) = %q, want %q", ago, got, want)
+		}
+	}
+
+	f := columns.Formatter{Columns: columns.Set(0).With(columns.Owner).With(columns.Size).List()}
+	rows := [][]string{f.Cells(tree.Metadata{Owner: "root", Size: 5}), f.Cells(tree.Metadata{Owner: "alice", Size: 12345})}
+	widths := columns.Widths(rows)
+	if got := f.Join(rows[0], widths); got != "root      5" {
+		t.Errorf("names should be left-aligned and sizes right-aligned, got %q", got)
+	}
+}
+
+func TestExportTextColumns(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 1)
+
+	var out bytes.Buffer
+	set := columns.Set(0).With(columns.Permissions).With(columns.Size)
+	if err := export.Write(&out, root, export.Options{Columns: set}); err != nil {
+		t.Fatal(err)
+	}
+	// Sizes are right-aligned to the widest one, which depends on the directory size
+	if !regexp.MustCompile(`\[-rw-r--r-- +14\]  file1\.txt`).MatchString(out.String()) {
+		t.Errorf("expected tree -ps style metadata, got:\n%s", out.String())
+	}
+}
+
+func TestUIColumnsToggle(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+	model.Update(tea.WindowSizeMsg{Width: 70, Height: 30})
+
+	if strings.Contains(model.View(), "-rw-r--r--") {
+		t.Fatal("columns should be hidden by default")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	view := model.View()
+	if !strings.Contains(view, "-rw-r--r--") || !strings.Contains(view, "just now") {
+		t.Errorf("L should show permissions and times, got:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "file1.txt") && lipgloss.Width(line) != 70 {
+			t.Errorf("columns should be aligned to the terminal width, got %d: %q", lipgloss.Width(line), line)
+		}
+	}
+
+	// A narrow terminal drops the less important columns instead of wrapping
+	model.Update(tea.WindowSizeMsg{Width: 40, Height: 30})
+	view = model.View()
+	if strings.Contains(view, "-rw-r--r--") || !strings.Contains(view, "just now") {
+		t.Errorf("narrow view should keep only the most important columns, got:\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	if strings.Contains(model.View(), "just now") {
+		t.Error("L should hide the columns again")
+	}
+}
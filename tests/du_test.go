@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupDiskUsageFixture creates directories of clearly different sizes
+func setupDiskUsageFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+	files := map[string]int{
+		"a_small/one.bin":     10,
+		"b_big/one.bin":       600,
+		"b_big/deep/two.bin":  400,
+		"c_medium.bin":        100,
+		"d_empty/.keep":       0,
+		"b_big/deep/more.bin": 0,
+	}
+	for relPath, size := range files {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestDiskUsage(t *testing.T) {
+	tmpDir := setupDiskUsageFixture(t)
+
+	size, err := tree.DiskUsage(context.Background(), filepath.Join(tmpDir, "b_big"))
+	if err != nil || size != 1000 {
+		t.Errorf("DiskUsage = %d, %v; want 1000", size, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tree.DiskUsage(ctx, tmpDir); err == nil {
+		t.Error("a cancelled walk should report the context error")
+	}
+}
+
+func TestUIDiskUsageMode(t *testing.T) {
+	tmpDir := setupDiskUsageFixture(t)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'U'}})
+	if cmd == nil {
+		t.Fatal("disk-usage mode should start measuring in the background")
+	}
+	drainCommands(t, model, cmd)
+
+	view := model.View()
+	var order []string
+	for _, line := range strings.Split(view, "\n") {
+		for _, name := range []string{"a_small", "b_big", "c_medium.bin", "d_empty"} {
+			if strings.Contains(line, name) {
+				order = append(order, name)
+			}
+		}
+	}
+	want := []string{"b_big", "c_medium.bin", "a_small", "d_empty"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("children should be sorted by size, got %v", order)
+	}
+	if !strings.Contains(view, "1.1K") || !strings.Contains(view, "█") || !strings.Contains(view, " 90%") {
+		t.Errorf("expected totals and percentage bars, got:\n%s", view)
+	}
+	if !strings.Contains(cursorLine(model.View()), filepath.Base(tmpDir)) {
+		t.Error("cursor should stay on the root while sorting")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'U'}})
+	if strings.Contains(model.View(), "█") {
+		t.Error("bars should disappear when disk-usage mode is off")
+	}
+	order = nil
+	for _, line := range strings.Split(model.View(), "\n") {
+		if strings.Contains(line, "a_small") || strings.Contains(line, "b_big") {
+			order = append(order, strings.TrimSpace(line))
+		}
+	}
+	if len(order) != 2 || !strings.Contains(order[0], "a_small") {
+		t.Errorf("name order should be restored, got %v", order)
+	}
+}
+
+func TestUIDiskUsageCancelsOnNavigation(t *testing.T) {
+	tmpDir := setupDiskUsageFixture(t)
+	model := ui.New(tree.Build(tmpDir, 2), 2, tmpDir)
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	for i := 0; i < 3; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if !strings.Contains(cursorLine(model.View()), "b_big") {
+		t.Fatalf("setup: cursor should be on b_big, got %q", cursorLine(model.View()))
+	}
+	_, stale := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'U'}})
+
+	// Leaving b_big for a_small cancels the measurement of b_big's subdirectories
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	drainCommands(t, model, stale)
+	drainCommands(t, model, cmd)
+
+	for _, line := range strings.Split(model.View(), "\n") {
+		if strings.Contains(line, "deep") && strings.Contains(line, "400") {
+			t.Errorf("cancelled measurement should not be recorded: %q", line)
+		}
+		if strings.Contains(line, "a_small") && !strings.Contains(line, "10") {
+			t.Errorf("the new scope should be measured: %q", line)
+		}
+	}
+}
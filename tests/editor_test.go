@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		visual, editor string
+		line           int
+		want           string
+	}{
+		{"", "nvim", 0, "nvim main.go"},
+		{"", "nvim", 42, "nvim +42 main.go"},
+		{"", "emacs -nw", 7, "emacs -nw +7 main.go"},
+		{"code --wait", "vim", 3, "code --wait --goto main.go:3"},
+		{"", "/usr/local/bin/hx", 9, "/usr/local/bin/hx main.go:9"},
+		{"", "", 0, "vi main.go"},
+	}
+	for _, tt := range tests {
+		t.Setenv("VISUAL", tt.visual)
+		t.Setenv("EDITOR", tt.editor)
+		cmd := fileops.EditorCommand("main.go", tt.line)
+		if got := strings.Join(cmd.Args, " "); got != tt.want {
+			t.Errorf("VISUAL=%q EDITOR=%q line %d: got %q, want %q", tt.visual, tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestEditKeys(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "true")
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+
+	// Directories are not handed to the editor
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if !strings.Contains(model.View(), "is a directory") {
+		t.Errorf("e on a directory should explain why nothing happened:\n%s", model.View())
+	}
+	drainCommands(t, model, cmd)
+
+	// A line suffix in the search still matches the file name
+	typeKeys(model, "/file2.go:12")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(cursorLine(model.View()), "file2.go") {
+		t.Errorf("search with a line number should find file2.go, got %q", cursorLine(model.View()))
+	}
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}}); cmd == nil {
+		t.Error("e on a file should start the editor")
+	}
+
+	// Enter in the finder with a line number reveals the file and starts the editor
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	drainCommands(t, model, cmd)
+	typeKeys(model, "nested:3")
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Error("Enter on a finder result with a line should start the editor")
+	}
+	if !strings.Contains(cursorLine(model.View()), "nested.txt") {
+		t.Errorf("the finder should reveal nested.txt, got %q", cursorLine(model.View()))
+	}
+}
@@ -0,0 +1,256 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/export"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextExportMatchesTreeLayout(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 2)
+
+	var buf bytes.Buffer
+	if err := export.Text(&buf, root, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testDir + `
+├── .hidden
+├── file1.txt
+├── file2.go
+└── subdir
+    ├── empty_dir
+    └── nested.txt
+
+2 directories, 4 files
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestTextExportRespectsDepth(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 1)
+
+	var buf bytes.Buffer
+	if err := export.Text(&buf, root, false); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testDir + `
+├── .hidden
+├── file1.txt
+├── file2.go
+└── subdir
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestTextExportNestedConnectors(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, relPath := range []string{"a/x/1.txt", "a/y.txt", "b.txt"} {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := export.Text(&buf, tree.Build(tmpDir, 3), true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := tmpDir + `
+├── a
+│   ├── x
+│   │   └── 1.txt
+│   └── y.txt
+└── b.txt
+
+2 directories, 3 files
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestJSONExportShape(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 2)
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, root, export.Options{Format: export.FormatJSON, Report: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(doc) != 2 {
+		t.Fatalf("expected root and report objects, got %d", len(doc))
+	}
+
+	rootEntry := doc[0]
+	if rootEntry["type"] != "directory" || rootEntry["path"] != testDir {
+		t.Errorf("unexpected root entry: %v", rootEntry)
+	}
+	contents, ok := rootEntry["contents"].([]any)
+	if !ok || len(contents) != 4 {
+		t.Fatalf("root should have 4 contents, got %v", rootEntry["contents"])
+	}
+	file := contents[1].(map[string]any)
+	if file["name"] != "file1.txt" || file["type"] != "file" || file["size"] != float64(len("sample content")) {
+		t.Errorf("unexpected file entry: %v", file)
+	}
+	if _, ok := file["time"]; !ok {
+		t.Error("entries should carry a modification time")
+	}
+
+	report := doc[1]
+	if report["type"] != "report" || report["directories"] != float64(2) || report["files"] != float64(4) {
+		t.Errorf("unexpected report: %v", report)
+	}
+}
+
+func TestXMLExportShape(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 1)
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, root, export.Options{Format: export.FormatXML, Report: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName   xml.Name `xml:"tree"`
+		Directory struct {
+			Name  string `xml:"name,attr"`
+			Files []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"file"`
+			Directories []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"directory"`
+		} `xml:"directory"`
+		Report struct {
+			Directories int `xml:"directories"`
+			Files       int `xml:"files"`
+		} `xml:"report"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if len(doc.Directory.Files) != 3 || len(doc.Directory.Directories) != 1 {
+		t.Errorf("unexpected contents: %+v", doc.Directory)
+	}
+	if doc.Report.Directories != 1 || doc.Report.Files != 3 {
+		t.Errorf("unexpected report: %+v", doc.Report)
+	}
+}
+
+func TestYAMLExport(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 2)
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, root, export.Options{Format: export.FormatYAML}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"- type: directory\n",
+		"  contents:\n    - type: file\n      name: \".hidden\"\n",
+		"        - type: directory\n          name: \"empty_dir\"\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("YAML output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "report") {
+		t.Error("report should be omitted when not requested")
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := map[string]export.Format{
+		"out.json": export.FormatJSON,
+		"out.yaml": export.FormatYAML,
+		"out.yml":  export.FormatYAML,
+		"out.XML":  export.FormatXML,
+		"out.txt":  export.FormatText,
+		"out":      export.FormatText,
+	}
+	for path, want := range tests {
+		if got := export.FormatForPath(path); got != want {
+			t.Errorf("FormatForPath(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := export.ParseFormat("csv"); err == nil {
+		t.Error("ParseFormat should reject unknown formats")
+	}
+}
+
+func TestUIExportExpandedView(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	typeKeys(model, "X")
+	typeLine(model, "view.txt")
+
+	data, err := os.ReadFile(filepath.Join(rootPath, "view.txt"))
+	if err != nil {
+		t.Fatalf("export file not written: %v", err)
+	}
+	output := string(data)
+	if !strings.Contains(output, "└── subdir") || strings.Contains(output, "nested.txt") {
+		t.Errorf("export should contain only the expanded view:\n%s", output)
+	}
+	if !strings.Contains(model.View(), "view.txt") {
+		t.Error("exported file should appear in the reloaded tree")
+	}
+
+	// An existing file is only replaced after confirming, and can be restored
+	exported := filepath.Join(rootPath, "view.txt")
+	if err := os.WriteFile(exported, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	typeKeys(model, "X")
+	typeLine(model, "view.txt")
+	if !strings.Contains(model.View(), "Replace existing view.txt? [y/N]") {
+		t.Fatalf("exporting over a file should ask first:\n%s", model.View())
+	}
+	typeKeys(model, "n")
+	if data, _ := os.ReadFile(exported); string(data) != "notes" {
+		t.Errorf("answering no should keep the file, got %q", data)
+	}
+	typeKeys(model, "X")
+	typeLine(model, "view.txt")
+	typeKeys(model, "y")
+	if data, _ := os.ReadFile(exported); !strings.Contains(string(data), "└── subdir") {
+		t.Errorf("answering yes should write the export, got %q", data)
+	}
+	typeKeys(model, "uu")
+	if data, _ := os.ReadFile(exported); string(data) != "notes" {
+		t.Errorf("undoing the export and the replacement should bring back the file, got %q", data)
+	}
+}
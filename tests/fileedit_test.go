@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFileOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "notes", "todo.txt")
+	if err := fileops.CreateFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.CreateFile(file); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("creating an existing file should fail with ErrExist, got %v", err)
+	}
+	if err := os.WriteFile(file, []byte("milk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(file, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.CreateDir(filepath.Join(tmpDir, "notes", "old")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copies are recursive and keep contents and permissions
+	notes := filepath.Join(tmpDir, "notes")
+	backup := filepath.Join(tmpDir, "backup")
+	if err := fileops.Copy(notes, backup); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(backup, "todo.txt"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("copied file should keep its mode, got %v, %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(backup, "old")); err != nil {
+		t.Error("empty directories should be copied")
+	}
+	if err := fileops.Copy(notes, filepath.Join(notes, "old", "nested")); err == nil {
+		t.Error("copying a directory into itself should fail")
+	}
+	if err := fileops.Copy(notes, backup); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("copy should not overwrite, got %v", err)
+	}
+
+	renamed, err := fileops.Rename(file, "done.txt")
+	if err != nil || renamed != filepath.Join(notes, "done.txt") {
+		t.Fatalf("Rename = %q, %v", renamed, err)
+	}
+	if _, err := fileops.Rename(renamed, "../escape.txt"); err == nil {
+		t.Error("names containing separators should be rejected")
+	}
+	if err := fileops.Move(renamed, filepath.Join(backup, "done.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.Delete(notes); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(notes); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Delete should remove directories recursively")
+	}
+}
+
+func TestTreeEditsKeepState(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 2)
+	subdir := childNames(root)["subdir"]
+
+	if err := os.Rename(filepath.Join(testDir, "subdir"), filepath.Join(testDir, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	moved := subdir.Move(root, "moved")
+	if moved == nil || !moved.IsExpanded {
+		t.Fatal("a moved directory should keep its expansion state")
+	}
+	nested := childNames(moved)["nested.txt"]
+	if nested == nil || nested.Path != filepath.Join(testDir, "moved", "nested.txt") || nested.Parent != moved {
+		t.Errorf("children should follow the moved directory, got %+v", nested)
+	}
+	if _, ok := childNames(root)["subdir"]; ok {
+		t.Error("the old node should be detached")
+	}
+	if got := strings.Join(childOrder(root), ","); got != ".hidden,file1.txt,file2.go,moved" {
+		t.Errorf("moved node should be sorted into place, got %s", got)
+	}
+}
+
+// typeLine enters text into the open prompt and submits it
+func typeLine(model *ui.Model, text string) {
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	typeKeys(model, text)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func TestUIFileOperations(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+	moveTo := func(name string) {
+		t.Helper()
+		for i := 0; i < 20 && !strings.Contains(cursorLine(model.View()), name); i++ {
+			model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		}
+		if !strings.Contains(cursorLine(model.View()), name) {
+			t.Fatalf("cursor should reach %s", name)
+		}
+	}
+
+	// Creating inside an unexpanded directory reveals the new file
+	moveTo("subdir")
+	typeKeys(model, "a")
+	typeLine(model, "docs/new.md")
+	if _, err := os.Stat(filepath.Join(testDir, "subdir", "docs", "new.md")); err != nil {
+		t.Fatal("a should create the file and its parents")
+	}
+	if !strings.Contains(cursorLine(model.View()), "new.md") {
+		t.Errorf("cursor should move to the new file, got %q", cursorLine(model.View()))
+	}
+
+	// Rename keeps the cursor on the entry
+	typeKeys(model, "r")
+	typeLine(model, "renamed.md")
+	if !strings.Contains(cursorLine(model.View()), "renamed.md") || strings.Contains(model.View(), "── new.md") {
+		t.Errorf("rename should update the node in place:\n%s", model.View())
+	}
+
+	// A name leading out of the directory is rejected before anything is replaced
+	typeKeys(model, "r")
+	typeLine(model, "../nested.txt")
+	if view := model.View(); strings.Contains(view, "Replace existing") || !strings.Contains(view, "invalid name") {
+		t.Errorf("rename should reject names with a separator without asking:\n%s", view)
+	}
+	if _, err := os.Stat(filepath.Join(testDir, "subdir", "nested.txt")); err != nil {
+		t.Error("a rejected rename should leave the existing file alone")
+	}
+
+	// Copying onto an existing file asks first
+	typeKeys(model, "c")
+	typeLine(model, "file1.txt")
+	if !strings.Contains(model.View(), "Replace existing file1.txt?") {
+		t.Fatal("copying onto an existing file should ask for confirmation")
+	}
+	typeKeys(model, "n")
+	if content, _ := os.ReadFile(filepath.Join(testDir, "file1.txt")); string(content) != "sample content" {
+		t.Error("declining should leave the existing file alone")
+	}
+
+	// Moving into a directory keeps the name, like mv
+	typeKeys(model, "m")
+	typeLine(model, ".")
+	if _, err := os.Stat(filepath.Join(testDir, "renamed.md")); err != nil {
+		t.Fatal("m should move the file into the root")
+	}
+	if !strings.Contains(cursorLine(model.View()), "renamed.md") {
+		t.Errorf("cursor should follow the moved file, got %q", cursorLine(model.View()))
+	}
+
+	// Permanent deletion needs a y
+	typeKeys(model, "D")
+	if !strings.Contains(model.View(), "Delete renamed.md permanently?") {
+		t.Fatal("D should ask before deleting")
+	}
+	typeKeys(model, "y")
+	if _, err := os.Stat(filepath.Join(testDir, "renamed.md")); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("confirming should delete the file")
+	}
+	if strings.Contains(model.View(), "── renamed.md") {
+		t.Error("the deleted node should disappear")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyHome})
+	for i := 0; i < 20; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	typeKeys(model, "D")
+	if !strings.Contains(model.View(), "Cannot delete the root directory") {
+		t.Error("the root should not be deletable")
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupFilterFixture creates a small repository layout with protos and tests
+func setupFilterFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+	for _, relPath := range []string{
+		"api/v1/service.proto",
+		"api/v1/service.pb.go",
+		"cmd/main.go",
+		"cmd/main_test.go",
+		"docs/README.md",
+	} {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestPatternSetMatch(t *testing.T) {
+	tests := []struct {
+		patterns  []string
+		name, rel string
+		want      bool
+	}{
+		{[]string{"*.proto"}, "service.proto", "api/v1/service.proto", true},
+		{[]string{"*.proto"}, "service.pb.go", "api/v1/service.pb.go", false},
+		{[]string{"*.md|*.go"}, "main.go", "cmd/main.go", true},
+		{[]string{"re:_test\\.go$"}, "main_test.go", "cmd/main_test.go", true},
+		{[]string{"/v[0-9]+/"}, "v1", "api/v1", true},
+		{[]string{"api/**/*.go"}, "service.pb.go", "api/v1/service.pb.go", true},
+		{[]string{"api/**/*.go"}, "main.go", "cmd/main.go", false},
+		{[]string{"!*_test.go"}, "main.go", "cmd/main.go", true},
+		{[]string{"!*_test.go"}, "main_test.go", "cmd/main_test.go", false},
+		{[]string{"*.go", "!*_test.go"}, "main_test.go", "cmd/main_test.go", false},
+		{nil, "main.go", "cmd/main.go", false},
+	}
+	for _, tt := range tests {
+		set, err := tree.ParsePatterns(tt.patterns)
+		if err != nil {
+			t.Fatalf("ParsePatterns(%q): %v", tt.patterns, err)
+		}
+		if got := set.Match(tt.name, tt.rel); got != tt.want {
+			t.Errorf("%q.Match(%q, %q) = %v, want %v", tt.patterns, tt.name, tt.rel, got, tt.want)
+		}
+	}
+
+	if _, err := tree.ParsePattern("re:(unclosed"); err == nil {
+		t.Error("invalid regular expressions should be rejected")
+	}
+}
+
+func TestIncludeExcludeOptions(t *testing.T) {
+	tmpDir := setupFilterFixture(t)
+	include, _ := tree.ParsePatterns([]string{"*.go"})
+	exclude, _ := tree.ParsePatterns([]string{"*_test.go|docs"})
+
+	root := tree.BuildWithOptions(tmpDir, 3, &tree.Options{Include: include, Exclude: exclude})
+	names := childNames(root)
+	if _, ok := names["docs"]; ok {
+		t.Error("-I should exclude directories too")
+	}
+	cmd := names["cmd"]
+	if cmd == nil {
+		t.Fatal("directories should be kept by -P")
+	}
+	if got := strings.Join(childOrder(cmd), ","); got != "main.go" {
+		t.Errorf("cmd children = %s, want main.go", got)
+	}
+	if _, ok := childNames(childNames(names["api"])["v1"])["service.proto"]; ok {
+		t.Error("-P should drop files that do not match")
+	}
+}
+
+func TestUIFilterPrompt(t *testing.T) {
+	tmpDir := setupFilterFixture(t)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	typeKeys(model, "f*.proto")
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+
+	view := model.View()
+	if !strings.Contains(view, "service.proto") {
+		t.Errorf("filter should load matches from unopened directories:\n%s", view)
+	}
+	for _, hidden := range []string{"cmd", "docs", "service.pb.go"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("%s should be pruned by the filter:\n%s", hidden, view)
+		}
+	}
+	if !strings.Contains(view, "v1") {
+		t.Error("ancestors of matches should stay visible")
+	}
+
+	// Invalid patterns report an error and keep the previous filter
+	typeKeys(model, "f")
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	typeKeys(model, "re:(")
+	if !strings.Contains(model.View(), "invalid pattern") {
+		t.Error("invalid regex should be reported while typing")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !strings.Contains(model.View(), "service.proto") || strings.Contains(model.View(), "docs") {
+		t.Error("Esc should restore the previous filter")
+	}
+
+	// An empty filter shows everything again
+	typeKeys(model, "f")
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.View(), "docs") {
+		t.Error("clearing the filter should show every node")
+	}
+}
+
+func TestUIFilterDuringLoad(t *testing.T) {
+	tmpDir := setupLargeDir(t, 600)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	// Start listing the large directory, but filter before any batch arrives
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, loading := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "f*.txt")
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+	drainCommands(t, model, loading)
+
+	if got := strings.Count(model.View(), "── file"); got != 600 {
+		t.Errorf("the filter should not list the loading directory a second time, got %d entries", got)
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// drainCommands runs cmd and feeds the resulting messages back into the model
+// until no further command is returned
+func drainCommands(t *testing.T, model *ui.Model, cmd tea.Cmd) {
+	t.Helper()
+	for i := 0; cmd != nil; i++ {
+		if i > 10000 {
+			t.Fatal("command chain did not terminate")
+		}
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				drainCommands(t, model, c)
+			}
+			return
+		}
+		_, cmd = model.Update(msg)
+	}
+}
+
+// cursorLine returns the rendered line under the cursor
+func cursorLine(view string) string {
+	for _, line := range strings.Split(view, "\n") {
+		if strings.HasPrefix(line, ">") {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestFinderRevealsDeepFile(t *testing.T) {
+	projectDir := setupComplexTestProject(t)
+	model := ui.New(tree.Build(projectDir, 1), 1, projectDir)
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if cmd == nil {
+		t.Fatal("Ctrl+P should start the background walk")
+	}
+	drainCommands(t, model, cmd)
+
+	typeKeys(model, "apihandler")
+	view := model.View()
+	if !strings.Contains(view, "internal/api/handler.go") {
+		t.Fatalf("finder should rank internal/api/handler.go, got:\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	line := cursorLine(model.View())
+	if !strings.Contains(line, "handler.go") {
+		t.Errorf("cursor should be on handler.go, got %q", line)
+	}
+}
+
+func TestFinderRanksBasenameMatchesFirst(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, relPath := range []string{"main_test/go_notes.txt", "cmd/main.go"} {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	drainCommands(t, model, cmd)
+	typeKeys(model, "main.go")
+
+	line := cursorLine(model.View())
+	if !strings.Contains(line, "cmd/main.go") {
+		t.Errorf("best match should be selected first, got %q", line)
+	}
+}
+
+func TestFinderRanksWhileIndexing(t *testing.T) {
+	tmpDir := setupLargeDir(t, 1500)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	// Typing before the walk finishes ranks every batch as it arrives
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	typeKeys(model, "e1499")
+	drainCommands(t, model, cmd)
+	view := model.View()
+	if !strings.Contains(cursorLine(view), "big/file1499.txt") {
+		t.Errorf("the best match should be selected, got %q", cursorLine(view))
+	}
+
+	// Ranking all paths again for the same query gives the same result
+	typeKeys(model, "x")
+	model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if model.View() != view {
+		t.Errorf("incremental and full ranking differ:\n%s\n---\n%s", view, model.View())
+	}
+
+	// Matches beyond the kept results are still counted
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if !strings.Contains(model.View(), "1501/1501") {
+		t.Errorf("every path should count as a match:\n%s", model.View()[:200])
+	}
+}
+
+func TestFinderEscapeCloses(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd != nil {
+		t.Error("Esc should close the finder without quitting")
+	}
+	if !strings.Contains(model.View(), "Controls:") {
+		t.Error("tree view should be shown again after closing the finder")
+	}
+}
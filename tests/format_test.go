@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"dtree/internal/format"
+	"testing"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:        "0",
+		1023:     "1023",
+		1024:     "1.0K",
+		1536:     "1.5K",
+		20 << 20: "20M",
+		5 << 40:  "5.0T",
+	}
+	for size, want := range tests {
+		if got := format.Size(size); got != want {
+			t.Errorf("Size(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"dtree/internal/git"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupGitRepo creates a committed repository with a mix of pending changes
+func setupGitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, ".config"))
+
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(relPath, content string) {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitCmd("init", "-q")
+	write(".gitignore", "*.log\n")
+	write("clean.txt", "clean")
+	write("modified.txt", "before")
+	write("pkg/old.go", "package pkg")
+	write("pkg/deep/staged.go", "package deep")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "initial")
+
+	write("modified.txt", "after")
+	write("untracked.txt", "new")
+	write("debug.log", "ignored")
+	write("pkg/deep/staged.go", "package deep // staged")
+	gitCmd("add", "pkg/deep/staged.go")
+	gitCmd("mv", "pkg/old.go", "pkg/new.go")
+
+	return tmpDir
+}
+
+func TestGitStatusLoad(t *testing.T) {
+	repo := setupGitRepo(t)
+
+	status, err := git.Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want git.State
+	}{
+		{"clean.txt", git.Clean},
+		{"modified.txt", git.Modified},
+		{"untracked.txt", git.Untracked},
+		{"debug.log", git.Ignored},
+		{"pkg/deep/staged.go", git.Staged},
+		{"pkg/new.go", git.Renamed},
+	}
+	for _, tt := range tests {
+		if got := status.Of(filepath.Join(repo, tt.path)); got != tt.want {
+			t.Errorf("Of(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !status.ContainsChanges(filepath.Join(repo, "pkg")) {
+		t.Error("pkg should contain changes")
+	}
+	if status.ContainsChanges(filepath.Join(repo, "clean.txt")) {
+		t.Error("clean file should not report contained changes")
+	}
+
+	changed := status.Changed()
+	if len(changed) != 4 {
+		t.Errorf("Changed() returned %d paths, want 4: %v", len(changed), changed)
+	}
+}
+
+func TestGitStatusCopies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	t.Setenv("HOME", repo)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(repo, ".config"))
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	content := "line one\nline two\nline three\nline four\n"
+	if err := os.WriteFile(filepath.Join(repo, "original.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd("init", "-q")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "initial")
+
+	// A copy of a modified file is reported as "C  copy.txt\0original.txt"
+	if err := os.WriteFile(filepath.Join(repo, "copy.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "original.txt"), []byte(content+"more\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd("config", "status.renames", "copies")
+	gitCmd("add", ".")
+
+	status, err := git.Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := status.Of(filepath.Join(repo, "copy.txt")); got != git.Staged {
+		t.Errorf("Of(copy.txt) = %v, want staged", got)
+	}
+	if changed := status.Changed(); len(changed) != 2 {
+		t.Errorf("the source of a copy should not be parsed as an entry, got %v", changed)
+	}
+}
+
+func TestGitStatusOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if _, err := git.Load(t.TempDir()); err == nil {
+		t.Error("Load should fail outside a repository")
+	}
+}
+
+func TestUIGitDecorations(t *testing.T) {
+	repo := setupGitRepo(t)
+	root := tree.Build(repo, 1)
+	model := ui.New(root, 1, repo)
+	drainCommands(t, model, model.Init()) // The status is read in the background
+
+	view := model.View()
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "modified.txt") && !strings.Contains(line, "M") {
+			t.Errorf("modified file should be decorated: %q", line)
+		}
+		if strings.Contains(line, "pkg") && !strings.Contains(line, "●") {
+			t.Errorf("collapsed directory with changes should be marked: %q", line)
+		}
+	}
+}
+
+func TestUIChangedOnlyFilter(t *testing.T) {
+	repo := setupGitRepo(t)
+	root := tree.Build(repo, 1)
+	model := ui.New(root, 1, repo)
+	drainCommands(t, model, model.Init()) // The status is read in the background
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'M'}})
+	view := model.View()
+
+	if strings.Contains(view, "clean.txt") {
+		t.Error("clean files should be filtered out")
+	}
+	for _, name := range []string{"modified.txt", "untracked.txt", "staged.go", "new.go"} {
+		if !strings.Contains(view, name) {
+			t.Errorf("changed file %s should be revealed", name)
+		}
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'M'}})
+	if !strings.Contains(model.View(), "clean.txt") {
+		t.Error("clean files should return after leaving changed-only mode")
+	}
+}
+
+// runCommands runs cmd and any batched commands, returning their messages without handling them
+func runCommands(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, c := range batch {
+		msgs = append(msgs, runCommands(c)...)
+	}
+	return msgs
+}
+
+func TestUIGitStatusRefreshesInBackground(t *testing.T) {
+	repo := setupGitRepo(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	model := ui.New(tree.Build(repo, 1), 1, repo)
+	drainCommands(t, model, model.Init())
+	create := func(name string) tea.Cmd {
+		typeKeys(model, "a")
+		model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+		typeKeys(model, name)
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return cmd
+	}
+	untracked := func(name string) bool {
+		for _, line := range strings.Split(model.View(), "\n") {
+			if strings.Contains(line, "── "+name) {
+				return strings.Contains(line, "?")
+			}
+		}
+		return false
+	}
+
+	first := create("first.txt")
+	if untracked("first.txt") {
+		t.Error("the status should be read in the background, not while creating the file")
+	}
+	read := runCommands(first) // Reads the status before second.txt exists
+
+	// A refresh asked for during the read runs once the read is done
+	drainCommands(t, model, create("second.txt"))
+	for _, msg := range read {
+		_, cmd := model.Update(msg)
+		drainCommands(t, model, cmd)
+	}
+	if !untracked("first.txt") || !untracked("second.txt") {
+		t.Errorf("both new files should be decorated as untracked:\n%s", model.View())
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHideHiddenFiles(t *testing.T) {
+	testDir := setupTestFixture(t)
+	if err := os.MkdirAll(filepath.Join(testDir, "subdir", ".cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root := tree.BuildWithOptions(testDir, 1, &tree.Options{HideHidden: true})
+	names := childNames(root)
+	if _, ok := names[".hidden"]; ok {
+		t.Error(".hidden should not be loaded")
+	}
+
+	// Lazily loaded directories follow the same policy
+	subdir := names["subdir"]
+	subdir.LoadChildren()
+	if _, ok := childNames(subdir)[".cache"]; ok {
+		t.Error(".cache should not be loaded when expanding subdir")
+	}
+
+	var walked []string
+	tree.Walk(testDir, root.Options(), func(path string, isDir bool) error {
+		walked = append(walked, filepath.Base(path))
+		return nil
+	})
+	for _, name := range walked {
+		if strings.HasPrefix(name, ".") {
+			t.Errorf("Walk should skip hidden entries, got %s", name)
+		}
+	}
+
+	root.Options().HideHidden = false
+	root.Reload()
+	if _, ok := childNames(root)[".hidden"]; !ok {
+		t.Error(".hidden should appear after showing hidden files")
+	}
+}
+
+func TestUIHiddenToggleKeepsNearestCursor(t *testing.T) {
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if !strings.Contains(cursorLine(model.View()), ".hidden") {
+		t.Fatalf("setup: cursor should be on .hidden, got %q", cursorLine(model.View()))
+	}
+
+	typeKeys(model, ".")
+	view := model.View()
+	if strings.Contains(view, ".hidden") {
+		t.Error(". should hide dotfiles")
+	}
+	if !strings.Contains(cursorLine(view), "file1.txt") {
+		t.Errorf("cursor should move to the nearest visible node, got %q", cursorLine(view))
+	}
+
+	typeKeys(model, ".")
+	if !strings.Contains(model.View(), ".hidden") {
+		t.Error(". again should show dotfiles")
+	}
+	if !strings.Contains(cursorLine(model.View()), "file1.txt") {
+		t.Error("cursor should stay put when nodes reappear")
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"dtree/internal/preview"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tokenKinds maps each token's text to its kind for easy assertions
+func tokenKinds(tokens []preview.Token) map[string]preview.TokenKind {
+	kinds := make(map[string]preview.TokenKind)
+	for _, token := range tokens {
+		kinds[token.Text] = token.Kind
+	}
+	return kinds
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path, firstLine, want string
+	}{
+		{"main.go", "", "go"},
+		{"App.TSX", "", "typescript"},
+		{"config.yml", "", "yaml"},
+		{"README.md", "", "markdown"},
+		{"deploy", "#!/usr/bin/env bash", "shell"},
+		{"tool", "#!/usr/bin/env python3", "python"},
+		{"server", "#!/usr/bin/node", "javascript"},
+		{"script", "#!/bin/sh -e", "shell"},
+		{"script", "#!/usr/bin/env perl", ""},
+		{"plain", "hello", ""},
+	}
+	for _, tt := range tests {
+		if got := preview.DetectLanguage(tt.path, tt.firstLine); got != tt.want {
+			t.Errorf("DetectLanguage(%q, %q) = %q, want %q", tt.path, tt.firstLine, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightGo(t *testing.T) {
+	h := preview.NewHighlighter("go")
+	kinds := tokenKinds(h.Line(`func main() { x := "hi" + 42 // done`))
+	if kinds["func"] != preview.TokenKeyword {
+		t.Error("func should be a keyword")
+	}
+	if kinds[`"hi"`] != preview.TokenString {
+		t.Error(`"hi" should be a string`)
+	}
+	if kinds["42"] != preview.TokenNumber {
+		t.Error("42 should be a number")
+	}
+	if kinds["// done"] != preview.TokenComment {
+		t.Error("trailing comment should be highlighted")
+	}
+
+	// Block comments and raw strings carry over to following lines
+	h.Line("/* start")
+	if tokens := h.Line("still comment */ var"); tokens[0].Kind != preview.TokenComment || tokenKinds(tokens)["var"] != preview.TokenKeyword {
+		t.Errorf("block comment should end mid-line: %+v", tokens)
+	}
+	h.Line("s := `raw")
+	if tokens := h.Line(`"still raw"`); len(tokens) != 1 || tokens[0].Kind != preview.TokenString {
+		t.Errorf("raw string should span lines: %+v", tokens)
+	}
+}
+
+func TestHighlightYAMLAndMarkdown(t *testing.T) {
+	yaml := preview.NewHighlighter("yaml")
+	kinds := tokenKinds(yaml.Line(`  - name: "build" # step`))
+	if kinds["name"] != preview.TokenKey || kinds[`"build"`] != preview.TokenString || kinds["# step"] != preview.TokenComment {
+		t.Errorf("unexpected YAML tokens: %+v", kinds)
+	}
+	if kinds := tokenKinds(yaml.Line("enabled: true")); kinds["true"] != preview.TokenKeyword {
+		t.Errorf("booleans should be highlighted: %+v", kinds)
+	}
+
+	md := preview.NewHighlighter("markdown")
+	if tokens := md.Line("# Title"); tokens[0].Kind != preview.TokenHeading {
+		t.Error("headings should be highlighted")
+	}
+	kinds = tokenKinds(md.Line("Run `go test` and see [docs](https://example.com)"))
+	if kinds["`go test`"] != preview.TokenCode || kinds["[docs](https://example.com)"] != preview.TokenLink {
+		t.Errorf("unexpected inline tokens: %+v", kinds)
+	}
+	md.Line("```go")
+	if tokens := md.Line("# not a heading"); tokens[0].Kind != preview.TokenCode {
+		t.Error("fenced lines should be code")
+	}
+	md.Line("```")
+	if tokens := md.Line("# Heading again"); tokens[0].Kind != preview.TokenHeading {
+		t.Error("fence should close")
+	}
+
+	if preview.NewHighlighter("") != nil {
+		t.Error("unknown languages should not be highlighted")
+	}
+}
+
+func TestPreviewDetectsLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "run")
+	if err := os.WriteFile(path, []byte("#!/bin/bash\necho $HOME\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := preview.Load(path, 10)
+	if p.Language != "shell" {
+		t.Fatalf("Language = %q, want shell", p.Language)
+	}
+	kinds := tokenKinds(preview.NewHighlighter(p.Language).Line(p.Lines[1]))
+	if kinds["$HOME"] != preview.TokenVariable {
+		t.Errorf("shell variables should be highlighted: %+v", kinds)
+	}
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupIgnoreFixture creates a git-like project with several kinds of ignore files
+func setupIgnoreFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+
+	// Keep the user's global git excludes out of the test
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, ".config"))
+
+	files := map[string]string{
+		".gitignore":              "*.log\nnode_modules/\n/dist\n!keep.log\n",
+		".git/info/exclude":       "secret.txt\n",
+		".dtreeignore":            "docs/\n",
+		"main.go":                 "package main",
+		"debug.log":               "log",
+		"keep.log":                "log",
+		"secret.txt":              "secret",
+		"node_modules/pkg/a.js":   "js",
+		"dist/bundle.js":          "js",
+		"docs/readme.md":          "docs",
+		"src/.gitignore":          "generated/\n",
+		"src/app.go":              "package src",
+		"src/generated/gen.go":    "package generated",
+		"src/dist/not_anchor.txt": "kept because /dist is anchored at the root",
+	}
+
+	for relPath, content := range files {
+		fullPath := filepath.Join(tmpDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return tmpDir
+}
+
+func childNames(node *tree.Node) map[string]*tree.Node {
+	names := make(map[string]*tree.Node)
+	for _, child := range node.Children {
+		names[child.Name] = child
+	}
+	return names
+}
+
+func TestBuildHidesIgnoredEntries(t *testing.T) {
+	projectDir := setupIgnoreFixture(t)
+	root := tree.BuildWithOptions(projectDir, 2, &tree.Options{HideIgnored: true})
+
+	children := childNames(root)
+	for _, hidden := range []string{"debug.log", "node_modules", "dist", "secret.txt", "docs"} {
+		if _, ok := children[hidden]; ok {
+			t.Errorf("%s should be hidden by ignore rules", hidden)
+		}
+	}
+	for _, visible := range []string{"main.go", "keep.log", "src", ".gitignore"} {
+		if _, ok := children[visible]; !ok {
+			t.Errorf("%s should be visible", visible)
+		}
+	}
+
+	src := children["src"]
+	if src == nil {
+		t.Fatal("src should be loaded")
+	}
+	srcChildren := childNames(src)
+	if _, ok := srcChildren["generated"]; ok {
+		t.Error("nested .gitignore should hide src/generated")
+	}
+	if _, ok := srcChildren["dist"]; !ok {
+		t.Error("anchored /dist pattern should not hide src/dist")
+	}
+}
+
+func TestBuildMarksIgnoredEntries(t *testing.T) {
+	projectDir := setupIgnoreFixture(t)
+	root := tree.Build(projectDir, 2)
+
+	children := childNames(root)
+	if len(children) != len(root.Children) {
+		t.Fatal("duplicate child names")
+	}
+	if node := children["debug.log"]; node == nil || !node.Ignored {
+		t.Error("debug.log should be loaded and marked ignored")
+	}
+	if node := children["main.go"]; node == nil || node.Ignored {
+		t.Error("main.go should not be marked ignored")
+	}
+
+	modules := children["node_modules"]
+	if modules == nil {
+		t.Fatal("node_modules should be loaded when ignored entries are shown")
+	}
+	for _, child := range modules.Children {
+		if !child.Ignored {
+			t.Errorf("%s should inherit ignored state from its parent", child.Name)
+		}
+	}
+}
+
+func TestReloadKeepsExpansion(t *testing.T) {
+	projectDir := setupIgnoreFixture(t)
+	root := tree.BuildWithOptions(projectDir, 2, &tree.Options{HideIgnored: true})
+
+	src := childNames(root)["src"]
+	if src == nil || !src.IsExpanded {
+		t.Fatal("src should start expanded")
+	}
+
+	root.Options().HideIgnored = false
+	root.Reload()
+
+	children := childNames(root)
+	if children["src"] != src {
+		t.Error("Reload should keep the existing node for src")
+	}
+	if !src.IsExpanded {
+		t.Error("Reload should keep src expanded")
+	}
+	if _, ok := children["debug.log"]; !ok {
+		t.Error("Reload should pick up entries that are no longer hidden")
+	}
+	if _, ok := childNames(src)["generated"]; !ok {
+		t.Error("Reload should refresh loaded subdirectories")
+	}
+}
+
+func TestUIToggleIgnored(t *testing.T) {
+	projectDir := setupIgnoreFixture(t)
+	root := tree.BuildWithOptions(projectDir, 1, &tree.Options{HideIgnored: true})
+	model := ui.New(root, 1, projectDir)
+
+	if strings.Contains(model.View(), "debug.log") {
+		t.Fatal("ignored file should be hidden initially")
+	}
+
+	toggle := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'I'}}
+	_, cmd := model.Update(toggle)
+	drainCommands(t, model, cmd) // Only refreshes the git status in the background
+	if !strings.Contains(model.View(), "debug.log") {
+		t.Error("ignored file should be shown after toggling")
+	}
+
+	model.Update(toggle)
+	if strings.Contains(model.View(), "debug.log") {
+		t.Error("ignored file should be hidden after toggling back")
+	}
+}
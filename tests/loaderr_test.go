@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/export"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPermissionDeniedIsRecorded(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced for this user")
+	}
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	if err := os.Mkdir(locked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	root := tree.Build(tmpDir, 2)
+	node := childNames(root)["locked"]
+	if node.Err == nil || node.ErrorSummary() != "permission denied" {
+		t.Errorf("ErrorSummary = %q, want permission denied", node.ErrorSummary())
+	}
+}
+
+func TestLoadErrorRenderedAndRetried(t *testing.T) {
+	tmpDir := t.TempDir()
+	flaky := filepath.Join(tmpDir, "flaky")
+	if err := os.Mkdir(flaky, 0755); err != nil {
+		t.Fatal(err)
+	}
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	// Swap the directory for a file so reading it fails
+	if err := os.Remove(flaky); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(flaky, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+	view := model.View()
+	if !strings.Contains(cursorLine(view), "flaky [not a directory]") {
+		t.Errorf("the error should be shown inline, got %q", cursorLine(view))
+	}
+	if !strings.Contains(view, "Cannot read "+flaky) {
+		t.Error("the error should be reported in the status line")
+	}
+
+	// Once the directory is readable again, Enter retries the load
+	if err := os.Remove(flaky); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(flaky, "inside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+	view = model.View()
+	if strings.Contains(view, "[not a directory]") || !strings.Contains(view, "inside") {
+		t.Errorf("retry should load the directory:\n%s", view)
+	}
+}
+
+func TestLoadErrorInPrintMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := export.Write(&out, tree.Build(path, 1), export.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[not a directory]") {
+		t.Errorf("print mode should show the error, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := export.Write(&out, tree.Build(path, 1), export.Options{Format: export.FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"error": "not a directory"`) {
+		t.Errorf("JSON should carry the error, got %s", out.String())
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// markedLine returns the rendered line showing name with a mark indicator
+func markedLine(view, name string) bool {
+	for _, line := range strings.Split(view, "\n") {
+		if len(line) > 1 && line[1] == '*' && strings.Contains(line, " "+name) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMarksAndBulkActions(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataDir)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+	var clipboard bytes.Buffer
+	model.SetClipboard(&clipboard)
+
+	// The root cannot be marked; t marks and moves down
+	typeKeys(model, "tjt")
+	if !markedLine(model.View(), "file1.txt") || !strings.Contains(model.View(), "[1 marked]") {
+		t.Fatalf("t should mark file1.txt:\n%s", model.View())
+	}
+	typeKeys(model, "*")
+	if markedLine(model.View(), "file1.txt") || !markedLine(model.View(), "file2.go") || !markedLine(model.View(), "subdir") {
+		t.Errorf("* should invert the marks:\n%s", model.View())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if strings.Contains(model.View(), "marked]") {
+		t.Error("Esc should clear the marks")
+	}
+
+	typeKeys(model, "+")
+	typeLine(model, "*.txt *.go")
+	if !strings.Contains(model.View(), "[2 marked]") || markedLine(model.View(), "subdir") {
+		t.Fatalf("+ should mark entries matching the patterns:\n%s", model.View())
+	}
+
+	// y copies every marked path, one per line
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	drainCommands(t, model, cmd)
+	want := filepath.Join(testDir, "file1.txt") + "\n" + filepath.Join(testDir, "file2.go")
+	if !strings.Contains(clipboard.String(), base64.StdEncoding.EncodeToString([]byte(want))) {
+		t.Errorf("y should write the marked paths as OSC 52, got %q", clipboard.String())
+	}
+
+	// Moving marked entries puts each into the chosen directory
+	typeKeys(model, "m")
+	typeLine(model, "subdir")
+	for _, name := range []string{"file1.txt", "file2.go"} {
+		if _, err := os.Stat(filepath.Join(testDir, "subdir", name)); err != nil {
+			t.Errorf("%s should have moved into subdir", name)
+		}
+	}
+	if strings.Contains(model.View(), "marked]") {
+		t.Error("marks should be cleared after a bulk action")
+	}
+	if !strings.Contains(cursorLine(model.View()), "subdir") {
+		t.Errorf("cursor should move to the destination, got %q", cursorLine(model.View()))
+	}
+
+	// V extends a range from the anchor to the cursor
+	typeKeys(model, "jjVj")
+	if !strings.Contains(model.View(), "VISUAL") || !markedLine(model.View(), "file2.go") {
+		t.Fatalf("V should highlight the range:\n%s", model.View())
+	}
+	typeKeys(model, "V")
+	if !strings.Contains(model.View(), "[2 marked]") {
+		t.Fatalf("V again should mark the range:\n%s", model.View())
+	}
+	typeKeys(model, "d")
+	if !strings.Contains(model.View(), "Move 2 marked entries to the trash?") {
+		t.Fatal("d with marks should ask before trashing them")
+	}
+	typeKeys(model, "y")
+	for _, name := range []string{"file1.txt", "file2.go"} {
+		if _, err := os.Stat(filepath.Join(testDir, "subdir", name)); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s should have been trashed", name)
+		}
+	}
+	items, err := fileops.Trash{Dir: filepath.Join(dataDir, "Trash")}.List()
+	if err != nil || len(items) != 2 {
+		t.Errorf("both entries should be in the trash, got %v, %v", items, err)
+	}
+
+	// A bulk action is undone and redone as a whole
+	typeKeys(model, "u")
+	for _, name := range []string{"file1.txt", "file2.go"} {
+		if _, err := os.Stat(filepath.Join(testDir, "subdir", name)); err != nil {
+			t.Errorf("u should restore every trashed entry, %s is missing", name)
+		}
+	}
+	if !strings.Contains(model.View(), "Undid trash of 2 entries") {
+		t.Errorf("the undo should be reported for the group:\n%s", model.View())
+	}
+	typeKeys(model, "u")
+	for _, name := range []string{"file1.txt", "file2.go"} {
+		if _, err := os.Stat(filepath.Join(testDir, name)); err != nil {
+			t.Errorf("u again should undo the whole move, %s is missing", name)
+		}
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	for _, name := range []string{"file1.txt", "file2.go"} {
+		if _, err := os.Stat(filepath.Join(testDir, "subdir", name)); err != nil {
+			t.Errorf("Ctrl+R should redo the whole move, %s is missing", name)
+		}
+	}
+}
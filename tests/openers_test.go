@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const sampleOpeners = `# Team defaults
+*.log         pager       cat {path}
+*.md          foreground  glow -p {path}
+mime:image/*  detached    feh --title "{name} in {dir}" {path}
+**/docs/*.txt detached    less +{line} {path}
+`
+
+func TestParseOpeners(t *testing.T) {
+	rules, err := fileops.ParseOpeners(strings.NewReader(sampleOpeners), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 4 || rules[0].Mode != fileops.OpenPager || rules[2].Pattern != "mime:image/*" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if got := strings.Join(rules[2].Command, "|"); got != "feh|--title|{name} in {dir}|{path}" {
+		t.Errorf("quoted arguments should stay together, got %q", got)
+	}
+
+	for _, bad := range []string{"*.log pager", "*.log sometimes less {path}", `*.log pager "less {path}`} {
+		if _, err := fileops.ParseOpeners(strings.NewReader("\n"+bad), "openers"); err == nil || !strings.HasPrefix(err.Error(), "openers:2:") {
+			t.Errorf("%q should fail with a line number, got %v", bad, err)
+		}
+	}
+
+	missing, err := fileops.LoadOpeners(filepath.Join(t.TempDir(), "openers"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("a missing rule file should mean no rules, got %v, %v", missing, err)
+	}
+}
+
+func TestOpenerRules(t *testing.T) {
+	rules, err := fileops.ParseOpeners(strings.NewReader(sampleOpeners), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := t.TempDir()
+	write := func(rel string, content string) string {
+		path := filepath.Join(tmpDir, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	logFile := write("app.log", "started\n")
+	photo := write("my photo", "\x89PNG\r\n\x1a\n")
+	readme := write("docs/readme.txt", "hi")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{logFile, "cat"},
+		{photo, "feh"}, // No extension, detected from the content
+		{readme, "less"},
+	}
+	for _, tt := range tests {
+		rule, err := rules.Rule(tmpDir, tt.path)
+		if err != nil || rule.Command[0] != tt.want {
+			t.Errorf("%s: got %v, %v; want %s", filepath.Base(tt.path), rule.Command, err, tt.want)
+		}
+	}
+	if _, err := rules.Rule(tmpDir, filepath.Join(tmpDir, "missing.log")); err == nil {
+		t.Error("opening a missing file should fail")
+	}
+	mac := fileops.Platform{OS: "darwin"}
+	if rule, err := rules.RuleFor(mac, tmpDir, write("notes.go", "package notes")); err != nil || rule.Command[0] != "open" {
+		t.Errorf("unmatched files should use the system opener, got %v, %v", rule.Command, err)
+	}
+
+	// Placeholders are filled in per argument, so paths with spaces stay whole
+	rule, _ := rules.Rule(tmpDir, photo)
+	if got := strings.Join(rule.Cmd(photo, 0).Args, "|"); got != "feh|--title|my photo in "+tmpDir+"|"+photo {
+		t.Errorf("unexpected arguments %q", got)
+	}
+	rule, _ = rules.Rule(tmpDir, readme)
+	if got := rule.Cmd(readme, 12).Args[1]; got != "+12" {
+		t.Errorf("{line} should be the requested line, got %q", got)
+	}
+	if got := rule.Cmd(readme, 0).Args[1]; got != "+1" {
+		t.Errorf("{line} should default to 1, got %q", got)
+	}
+}
+
+func TestOpenerSlashPattern(t *testing.T) {
+	rules, err := fileops.ParseOpeners(strings.NewReader("docs/*.md foreground glow {path}"), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	for _, rel := range []string{"docs/guide.md", "guide.md"} {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Slash patterns are relative to the tree root, like -P
+	mac := fileops.Platform{OS: "darwin"}
+	if rule, err := rules.RuleFor(mac, root, filepath.Join(root, "docs", "guide.md")); err != nil || rule.Command[0] != "glow" {
+		t.Errorf("docs/*.md should match docs/guide.md, got %v, %v", rule.Command, err)
+	}
+	if rule, err := rules.RuleFor(mac, root, filepath.Join(root, "guide.md")); err != nil || rule.Command[0] != "open" {
+		t.Errorf("docs/*.md should not match guide.md, got %v, %v", rule.Command, err)
+	}
+}
+
+func TestOpenerPagerMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses cat")
+	}
+	t.Setenv("PAGER", "cat -n")
+	rules, err := fileops.ParseOpeners(strings.NewReader("*.log pager cat {path}"), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logFile, []byte("started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule, err := rules.Rule(filepath.Dir(logFile), logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	cmd := rule.TerminalCommand(logFile, 0)
+	cmd.SetStdout(&out)
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "1\tstarted") {
+		t.Errorf("output should go through $PAGER, got %q", out.String())
+	}
+}
+
+func TestUIOpenerRules(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses cp")
+	}
+	testDir := setupTestFixture(t)
+	copied := filepath.Join(t.TempDir(), "opened.txt")
+	rules, err := fileops.ParseOpeners(strings.NewReader("*.txt detached cp {path} "+copied), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+	model.SetOpeners(rules)
+
+	typeKeys(model, "/file1")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	for i := 0; i < 100; i++ {
+		if content, err := os.ReadFile(copied); err == nil && string(content) == "sample content" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Enter should run the matching detached opener:\n%s", model.View())
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"dtree/internal/fileops"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lookPathIn returns a LookPath that only finds the given programs
+func lookPathIn(programs ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, program := range programs {
+			if program == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestSystemOpeners(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform fileops.Platform
+		want     string
+	}{
+		{"macOS", fileops.Platform{OS: "darwin"}, "open {path}"},
+		{"Windows", fileops.Platform{OS: "windows"}, "rundll32 url.dll,FileProtocolHandler {path}"},
+		{"WSL with wslu", fileops.Platform{OS: "linux", WSL: true, LookPath: lookPathIn("wslview", "explorer.exe", "wslpath")}, "wslview {path}"},
+		{"WSL without wslu", fileops.Platform{OS: "linux", WSL: true, LookPath: lookPathIn("explorer.exe", "wslpath", "xdg-open")}, "explorer.exe {path}"},
+		{"WSL without interop", fileops.Platform{OS: "linux", WSL: true, LookPath: lookPathIn("xdg-open")}, "xdg-open {path}"},
+		{"Linux", fileops.Platform{OS: "linux", LookPath: lookPathIn("xdg-open", "wslview")}, "xdg-open {path}"},
+		{"FreeBSD", fileops.Platform{OS: "freebsd", LookPath: lookPathIn("xdg-open")}, "xdg-open {path}"},
+		{"OpenBSD", fileops.Platform{OS: "openbsd", LookPath: lookPathIn("xdg-open")}, "xdg-open {path}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openers, err := fileops.SystemOpeners(tt.platform)
+			if err != nil || len(openers) != 1 {
+				t.Fatalf("SystemOpeners = %v, %v", openers, err)
+			}
+			if got := strings.Join(openers[0].Command, " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// No shell sees the path, so its metacharacters stay part of one argument
+	windows, _ := fileops.SystemOpeners(fileops.Platform{OS: "windows"})
+	risky := `C:\Users\me\a&calc.exe|x^y %PATH% "q".txt`
+	if args := windows[0].Cmd(risky, 0).Args; len(args) != 3 || args[0] != "rundll32" || args[2] != risky {
+		t.Errorf("the path should be passed as a single argument, got %q", args)
+	}
+
+	if _, err := fileops.SystemOpeners(fileops.Platform{OS: "linux", LookPath: lookPathIn()}); err == nil || !strings.Contains(err.Error(), "xdg-open") {
+		t.Errorf("a missing xdg-open should be explained, got %v", err)
+	}
+	if _, err := fileops.SystemOpeners(fileops.Platform{OS: "plan9"}); err == nil || !strings.Contains(err.Error(), "unsupported platform: plan9") {
+		t.Errorf("unknown platforms should be unsupported, got %v", err)
+	}
+}
+
+func TestOpenerRulesWithoutSystemOpener(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("started"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := fileops.ParseOpeners(strings.NewReader("*.log pager cat {path}"), "openers")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rules still work where no default application is known
+	plan9 := fileops.Platform{OS: "plan9"}
+	if rule, err := rules.RuleFor(plan9, filepath.Dir(file), file); err != nil || rule.Mode != fileops.OpenPager {
+		t.Errorf("a matching rule should be used, got %+v, %v", rule, err)
+	}
+	if _, err := fileops.Openers(nil).RuleFor(plan9, filepath.Dir(file), file); err == nil {
+		t.Error("without a rule or system opener the file cannot be opened")
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"dtree/internal/preview"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPreviewLoadText(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("one\n\ttwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := preview.Load(path, 3)
+	if p.Kind != preview.KindText {
+		t.Fatalf("Kind = %v, want text", p.Kind)
+	}
+	if len(p.Lines) != 3 || p.Lines[1] != "    two" {
+		t.Errorf("unexpected lines: %q", p.Lines)
+	}
+	if !p.Truncated {
+		t.Error("preview should be marked truncated")
+	}
+	if !strings.Contains(p.Info, "  20  ") {
+		t.Errorf("Info should contain the size, got %q", p.Info)
+	}
+}
+
+func TestPreviewLoadControlCharacters(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "escapes.txt")
+	content := "title\x1b]0;pwned\x07 \x1b[2Jclear\x7f\u009b\tend\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := preview.Load(path, 10)
+	if p.Kind != preview.KindText || len(p.Lines) != 1 {
+		t.Fatalf("unexpected preview: %v %q", p.Kind, p.Lines)
+	}
+	if want := "title␛]0;pwned␇ ␛[2Jclear␡\ufffd    end"; p.Lines[0] != want {
+		t.Errorf("control characters should be made visible, got %q, want %q", p.Lines[0], want)
+	}
+}
+
+func TestPreviewLoadFIFO(t *testing.T) {
+	if _, err := exec.LookPath("mkfifo"); err != nil {
+		t.Skip("mkfifo not available")
+	}
+	path := filepath.Join(t.TempDir(), "pipe")
+	if err := exec.Command("mkfifo", path).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *preview.Preview)
+	go func() { done <- preview.Load(path, 10) }()
+	select {
+	case p := <-done:
+		if p.Kind != preview.KindSpecial || p.Info == "" || len(p.Lines) != 0 {
+			t.Errorf("a FIFO should only be described, got %v %q %q", p.Kind, p.Info, p.Lines)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("previewing a FIFO should not block")
+	}
+}
+
+func TestPreviewLoadBinary(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "blob.bin")
+	if err := os.WriteFile(path, []byte("AB\x00\x01CD"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := preview.Load(path, 10)
+	if p.Kind != preview.KindBinary {
+		t.Fatalf("Kind = %v, want binary", p.Kind)
+	}
+	want := "00000000  41 42 00 01 43 44"
+	if len(p.Lines) != 1 || !strings.HasPrefix(p.Lines[0], want) || !strings.HasSuffix(p.Lines[0], "|AB..CD|") {
+		t.Errorf("unexpected hex dump: %q", p.Lines)
+	}
+}
+
+func TestPreviewLoadDirectoryAndErrors(t *testing.T) {
+	testDir := setupTestFixture(t)
+
+	p := preview.Load(testDir, 10)
+	if p.Kind != preview.KindDirectory {
+		t.Fatalf("Kind = %v, want directory", p.Kind)
+	}
+	if len(p.Lines) != 4 || p.Lines[0] != "subdir"+string(filepath.Separator) {
+		t.Errorf("directories should be listed first: %q", p.Lines)
+	}
+
+	missing := preview.Load(filepath.Join(testDir, "missing"), 10)
+	if missing.Kind != preview.KindError || missing.Err == nil {
+		t.Error("missing path should produce an error preview")
+	}
+}
+
+func TestUIPreviewFollowsCursor(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+	model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if cmd == nil {
+		t.Fatal("opening the preview should load it asynchronously")
+	}
+	if !strings.Contains(model.View(), "Loading preview") {
+		t.Error("preview should show a loading state before the load completes")
+	}
+	drainCommands(t, model, cmd)
+	if !strings.Contains(model.View(), "subdir/") {
+		t.Error("directory preview should list subdir")
+	}
+
+	// Move onto file1.txt
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	drainCommands(t, model, cmd)
+	view := model.View()
+	if !strings.Contains(view, "1 content1") {
+		t.Errorf("file preview should show numbered content, got:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, " │ ") && len([]rune(line)) > 120 {
+			t.Errorf("line exceeds terminal width: %q", line)
+		}
+	}
+
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if cmd != nil {
+		t.Error("closing the preview should not load anything")
+	}
+	if strings.Contains(model.View(), "content1") {
+		t.Error("preview should be hidden after toggling off")
+	}
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUIReloadKeys(t *testing.T) {
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 2), 2, testDir)
+
+	for !strings.Contains(cursorLine(model.View()), "nested.txt") {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	for path, content := range map[string]string{"subdir/added.txt": "", "top.txt": ""} {
+		if err := os.WriteFile(filepath.Join(testDir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// R on a file reloads its directory only
+	typeKeys(model, "R")
+	view := model.View()
+	if !strings.Contains(view, "added.txt") {
+		t.Error("R should show the new file in the cursor's directory")
+	}
+	if strings.Contains(view, "top.txt") {
+		t.Error("R should not reload directories outside the cursor's")
+	}
+	if !strings.Contains(cursorLine(view), "nested.txt") {
+		t.Errorf("the cursor should stay on nested.txt, got %q", cursorLine(view))
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	view = model.View()
+	if !strings.Contains(view, "top.txt") || !strings.Contains(view, "added.txt") {
+		t.Errorf("Ctrl+L should reload the whole tree:\n%s", view)
+	}
+	if !strings.Contains(cursorLine(view), "nested.txt") {
+		t.Error("subdir should stay expanded and the cursor in place after a full reload")
+	}
+}
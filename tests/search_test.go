@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys feeds a string to the model one rune at a time
+func typeKeys(model *ui.Model, text string) {
+	for _, r := range text {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestSearchVisibleNodes(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	typeKeys(model, "/file")
+	if !strings.Contains(model.View(), "/file") {
+		t.Error("View should show the search prompt")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.View(), "match 1 of 2") {
+		t.Errorf("Expected first of two matches, got view:\n%s", model.View())
+	}
+
+	typeKeys(model, "n")
+	if !strings.Contains(model.View(), "match 2 of 2") {
+		t.Error("n should move to the second match")
+	}
+
+	typeKeys(model, "n")
+	if !strings.Contains(model.View(), "match 1 of 2") {
+		t.Error("n should wrap around to the first match")
+	}
+
+	typeKeys(model, "N")
+	if !strings.Contains(model.View(), "match 2 of 2") {
+		t.Error("N should wrap backwards to the last match")
+	}
+}
+
+func TestSearchNotFound(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	typeKeys(model, "/zzz")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if !strings.Contains(model.View(), "Pattern not found: zzz") {
+		t.Error("View should report a missing pattern")
+	}
+}
+
+func TestSearchNonASCIIName(t *testing.T) {
+	rootPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootPath, "Ⱥa"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	// Ⱥ lowercases to a rune of a different byte length
+	typeKeys(model, "/a")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := model.View(); !strings.Contains(view, "match 1 of 1") {
+		t.Errorf("Expected one match, got view:\n%s", view)
+	}
+
+	typeKeys(model, "/ⱥ")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := model.View(); !strings.Contains(view, "Ⱥa") || !strings.Contains(view, "match 1 of 1") {
+		t.Errorf("Lower case search should fold non-ASCII names, got view:\n%s", view)
+	}
+}
+
+func TestSearchDeepLoadsDirectories(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	typeKeys(model, "/nested")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if strings.Contains(model.View(), "nested.txt") {
+		t.Fatal("shallow search should not load subdir")
+	}
+
+	typeKeys(model, "/")
+	model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	typeKeys(model, "nested")
+	if !strings.Contains(model.View(), "(deep)") {
+		t.Error("Tab should switch the prompt to deep search")
+	}
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainCommands(t, model, cmd)
+
+	view := model.View()
+	if !strings.Contains(view, "nested.txt") {
+		t.Error("deep search should reveal nested.txt")
+	}
+	if !strings.Contains(view, "match 1 of 1") {
+		t.Error("deep search should select the revealed match")
+	}
+}
+
+func TestSearchEscapeCancelsPrompt(t *testing.T) {
+	_, rootPath := createTestTree(t)
+	model := ui.New(tree.Build(rootPath, 1), 1, rootPath)
+
+	typeKeys(model, "/file")
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd != nil {
+		t.Error("Esc in the prompt should cancel the search, not quit")
+	}
+	if strings.Contains(model.View(), "/file") {
+		t.Error("prompt should be closed after Esc")
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupSortFixture creates names that sort differently under each key
+func setupSortFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+	files := map[string]int{
+		"file10.txt": 300,
+		"file2.txt":  100,
+		"Beta.go":    200,
+		"alpha.md":   50,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "zdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
+// childOrder lists the names of a node's children in order
+func childOrder(node *tree.Node) []string {
+	names := make([]string, len(node.Children))
+	for i, child := range node.Children {
+		names[i] = child.Name
+	}
+	return names
+}
+
+func TestSortOrders(t *testing.T) {
+	tmpDir := setupSortFixture(t)
+
+	tests := []struct {
+		order tree.SortOrder
+		want  string
+	}{
+		{tree.SortOrder{}, "Beta.go,alpha.md,file10.txt,file2.txt,zdir"},
+		{tree.SortOrder{Key: tree.SortNatural}, "alpha.md,Beta.go,file2.txt,file10.txt,zdir"},
+		{tree.SortOrder{Key: tree.SortCaseInsensitive, DirsFirst: true}, "zdir,alpha.md,Beta.go,file10.txt,file2.txt"},
+		{tree.SortOrder{Key: tree.SortExtension}, "zdir,Beta.go,alpha.md,file10.txt,file2.txt"},
+		{tree.SortOrder{Key: tree.SortNatural, Reverse: true}, "zdir,file10.txt,file2.txt,Beta.go,alpha.md"},
+		{tree.SortOrder{Key: tree.SortNatural, Reverse: true, DirsFirst: true}, "zdir,file10.txt,file2.txt,Beta.go,alpha.md"},
+	}
+	for _, tt := range tests {
+		root := tree.BuildWithOptions(tmpDir, 1, &tree.Options{Sort: tt.order})
+		if got := strings.Join(childOrder(root), ","); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.order, got, tt.want)
+		}
+	}
+
+	// Sizes put the largest file first; directories DirSize cannot measure go last
+	root := tree.BuildWithOptions(tmpDir, 1, &tree.Options{
+		Sort:    tree.SortOrder{Key: tree.SortSize},
+		DirSize: func(*tree.Node) (int64, bool) { return 0, false },
+	})
+	if got := strings.Join(childOrder(root), ","); got != "file10.txt,Beta.go,file2.txt,alpha.md,zdir" {
+		t.Errorf("size order: got %s", got)
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	for name, want := range map[string]tree.SortKey{"version": tree.SortNatural, "MTIME": tree.SortTime, "ext": tree.SortExtension} {
+		if got, err := tree.ParseSortKey(name); err != nil || got != want {
+			t.Errorf("ParseSortKey(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := tree.ParseSortKey("colour"); err == nil {
+		t.Error("unknown sort names should be rejected")
+	}
+}
+
+func TestUISortMenuKeepsCursor(t *testing.T) {
+	tmpDir := setupSortFixture(t)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+
+	// Move onto file2.txt, the fifth line in name order
+	for i := 0; i < 4; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if !strings.Contains(cursorLine(model.View()), "file2.txt") {
+		t.Fatalf("setup: cursor should be on file2.txt, got %q", cursorLine(model.View()))
+	}
+
+	typeKeys(model, "s")
+	if !strings.Contains(model.View(), "Sort: ") {
+		t.Fatal("s should open the sort menu")
+	}
+	typeKeys(model, "v")
+	view := model.View()
+	if strings.Contains(view, "Sort: ") {
+		t.Error("choosing a key should close the menu")
+	}
+	if strings.Index(view, "file2.txt") > strings.Index(view, "file10.txt") {
+		t.Errorf("version sort should put file2 before file10:\n%s", view)
+	}
+	if !strings.Contains(cursorLine(view), "file2.txt") {
+		t.Errorf("cursor should stay on file2.txt, got %q", cursorLine(view))
+	}
+
+	typeKeys(model, "S")
+	view = model.View()
+	if strings.Index(view, "file10.txt") > strings.Index(view, "file2.txt") || !strings.Contains(view, "reversed") {
+		t.Errorf("S should reverse the order:\n%s", view)
+	}
+	if !strings.Contains(cursorLine(view), "file2.txt") {
+		t.Errorf("cursor should stay on file2.txt after reversing, got %q", cursorLine(view))
+	}
+}
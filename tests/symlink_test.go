@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"dtree/internal/export"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupSymlinkFixture creates a directory link, a loop back to the root and a broken link
+func setupSymlinkFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "real", "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "real", "file.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"alias":          "real",
+		"real/inner/top": "../..",
+		"dangling":       "missing.txt",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tmpDir, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	return tmpDir
+}
+
+func TestSymlinksNotFollowed(t *testing.T) {
+	tmpDir := setupSymlinkFixture(t)
+	root := tree.Build(tmpDir, 1)
+	names := childNames(root)
+
+	alias := names["alias"]
+	if alias == nil || alias.Link == nil || alias.Link.Target != "real" {
+		t.Fatalf("alias should record its target, got %+v", alias)
+	}
+	if alias.IsDir {
+		t.Error("directory links should not be expandable unless following is enabled")
+	}
+	if dangling := names["dangling"]; dangling == nil || dangling.Link == nil || !dangling.Link.Broken {
+		t.Error("dangling should be flagged as a broken link")
+	}
+	if names["real"].Link != nil {
+		t.Error("regular directories should not have link information")
+	}
+}
+
+func TestSymlinksFollowedWithoutLooping(t *testing.T) {
+	tmpDir := setupSymlinkFixture(t)
+	root := tree.BuildWithOptions(tmpDir, 5, &tree.Options{FollowLinks: true})
+
+	alias := childNames(root)["alias"]
+	if !alias.IsDir {
+		t.Fatal("alias should be a directory when following links")
+	}
+	if _, ok := childNames(alias)["file.txt"]; !ok {
+		t.Error("following alias should list the target's contents")
+	}
+
+	top := childNames(childNames(childNames(root)["real"])["inner"])["top"]
+	if top == nil || !top.Link.Loop || len(top.Children) != 0 {
+		t.Errorf("a link back to the root should be detected as a loop, got %+v", top)
+	}
+
+	var out bytes.Buffer
+	if err := export.Write(&out, root, export.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "dangling -> missing.txt") || !strings.Contains(out.String(), "top -> ../..") {
+		t.Errorf("print mode should show link targets:\n%s", out.String())
+	}
+}
+
+func TestUISymlinkRendering(t *testing.T) {
+	tmpDir := setupSymlinkFixture(t)
+	root := tree.BuildWithOptions(tmpDir, 3, &tree.Options{FollowLinks: true})
+	model := ui.New(root, 3, tmpDir)
+
+	view := model.View()
+	for _, want := range []string{"alias -> real", "dangling -> missing.txt [broken]", "top -> ../.. [recursive, not followed]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q:\n%s", want, view)
+		}
+	}
+
+	// Enter on the looping link explains why it does not expand
+	for i := 0; i < 20 && !strings.Contains(cursorLine(model.View()), "top ->"); i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.View(), "Not following recursive symlink") {
+		t.Error("expanding a loop should report it")
+	}
+}
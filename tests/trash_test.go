@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTrashPutListRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	trash := fileops.Trash{Dir: filepath.Join(tmpDir, "Trash")}
+	first := filepath.Join(tmpDir, "my notes.txt")
+	for _, content := range []string{"one", "two"} {
+		if err := os.WriteFile(first, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := trash.Put(first); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.ReadFile(filepath.Join(trash.Dir, "info", "my notes.txt.trashinfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\n") || !strings.Contains(string(info), "my%20notes.txt\n") || !strings.Contains(string(info), "DeletionDate=") {
+		t.Errorf("unexpected .trashinfo contents:\n%s", info)
+	}
+
+	items, err := trash.List()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("List = %v, %v; want two items", items, err)
+	}
+	names := []string{items[0].Name, items[1].Name}
+	if !strings.Contains(strings.Join(names, ","), "my notes.2.txt") {
+		t.Errorf("the second item should get a unique name, got %v", names)
+	}
+	for _, item := range items {
+		if item.Original != first {
+			t.Errorf("Original = %q, want %q", item.Original, first)
+		}
+	}
+
+	if err := trash.Restore(items[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := trash.Restore(items[1]); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("restoring over an existing file should fail, got %v", err)
+	}
+	if err := trash.Purge(items[1]); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := trash.List(); len(items) != 0 {
+		t.Errorf("the trash should be empty, got %v", items)
+	}
+}
+
+func TestUITrashAndRestore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+
+	for !strings.Contains(cursorLine(model.View()), "subdir") {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	typeKeys(model, "d")
+	if !strings.Contains(model.View(), "Move directory subdir and everything in it to the trash? [y/N]") {
+		t.Fatalf("d should ask before trashing:\n%s", model.View())
+	}
+	typeKeys(model, "n")
+	if _, err := os.Stat(filepath.Join(testDir, "subdir")); err != nil {
+		t.Fatal("answering no should keep the directory")
+	}
+	typeKeys(model, "dy")
+	if _, err := os.Stat(filepath.Join(testDir, "subdir")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("d should move the directory away")
+	}
+	if strings.Contains(model.View(), "── ▶ subdir") || !strings.Contains(model.View(), "to the trash") {
+		t.Errorf("the trashed node should disappear:\n%s", model.View())
+	}
+
+	typeKeys(model, "T")
+	view := model.View()
+	if !strings.Contains(view, "Trash (1 items)") || !strings.Contains(cursorLine(view), "subdir") {
+		t.Fatalf("T should list the trashed directory:\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, err := os.Stat(filepath.Join(testDir, "subdir", "nested.txt")); err != nil {
+		t.Fatal("Enter should restore the directory with its contents")
+	}
+	typeKeys(model, "T")
+	if !strings.Contains(cursorLine(model.View()), "subdir") {
+		t.Errorf("the restored directory should be selected in the tree, got %q", cursorLine(model.View()))
+	}
+}
@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"dtree/internal/fileops"
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestJournalUndoRedo(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := t.TempDir()
+	newJournal := func() fileops.Journal {
+		return fileops.Journal{
+			Path:  filepath.Join(stateDir, "journal.json"),
+			Trash: fileops.Trash{Dir: filepath.Join(stateDir, "Trash")},
+		}
+	}
+	a, b := filepath.Join(tmpDir, "a.txt"), filepath.Join(tmpDir, "b.txt")
+
+	journal := newJournal()
+	if err := fileops.CreateFile(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(fileops.Operation{Kind: fileops.OpCreate, Path: a}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.Move(a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(fileops.Operation{Kind: fileops.OpMove, Path: a, Target: b}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh journal value reads the history back from disk
+	journal = newJournal()
+	if op, err := journal.Undo(); err != nil || op.Kind != fileops.OpMove {
+		t.Fatalf("Undo = %v, %v; want the move", op, err)
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Error("undoing the move should bring a.txt back")
+	}
+	if _, err := journal.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(a); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("undoing the create should remove a.txt")
+	}
+	if items, _ := journal.Trash.List(); len(items) != 1 {
+		t.Error("undone creations should be kept in the trash")
+	}
+	if _, err := journal.Undo(); !errors.Is(err, fileops.ErrNothingToUndo) {
+		t.Errorf("an exhausted history should report ErrNothingToUndo, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := journal.Redo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Error("redoing both operations should leave b.txt")
+	}
+	if _, err := journal.Redo(); !errors.Is(err, fileops.ErrNothingToRedo) {
+		t.Errorf("got %v, want ErrNothingToRedo", err)
+	}
+
+	// Undo of a trashed entry restores it; redo trashes it again
+	item, err := journal.Trash.Put(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(fileops.TrashOperation(journal.Trash, item)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Error("undo should restore the trashed file")
+	}
+	if _, err := journal.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(); err != nil {
+		t.Error("a redone trash should be undoable again")
+	}
+}
+
+func TestUIUndoRedo(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+
+	for !strings.Contains(cursorLine(model.View()), "file1.txt") {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	typeKeys(model, "r")
+	typeLine(model, "notes.txt")
+	typeKeys(model, "dy")
+	if _, err := os.Stat(filepath.Join(testDir, "notes.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("setup: notes.txt should be in the trash")
+	}
+
+	typeKeys(model, "u")
+	if !strings.Contains(cursorLine(model.View()), "notes.txt") {
+		t.Errorf("undoing the trash should restore notes.txt under the cursor, got %q", cursorLine(model.View()))
+	}
+	typeKeys(model, "u")
+	if _, err := os.Stat(filepath.Join(testDir, "file1.txt")); err != nil {
+		t.Error("undoing the rename should restore file1.txt")
+	}
+	if !strings.Contains(cursorLine(model.View()), "file1.txt") {
+		t.Errorf("cursor should follow the undone rename, got %q", cursorLine(model.View()))
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if !strings.Contains(cursorLine(model.View()), "notes.txt") || !strings.Contains(model.View(), "Redid move") {
+		t.Errorf("Ctrl+R should redo the rename:\n%s", model.View())
+	}
+
+	// The history survives a restart
+	restarted := ui.New(tree.Build(testDir, 1), 1, testDir)
+	typeKeys(restarted, "u")
+	if _, err := os.Stat(filepath.Join(testDir, "file1.txt")); err != nil {
+		t.Error("a new session should still be able to undo")
+	}
+}
+
+func TestJournalDropsStaleOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := t.TempDir()
+	journal := fileops.Journal{
+		Path:  filepath.Join(stateDir, "journal.json"),
+		Trash: fileops.Trash{Dir: filepath.Join(stateDir, "Trash")},
+	}
+	a, b := filepath.Join(tmpDir, "a.txt"), filepath.Join(tmpDir, "b.txt")
+	if err := fileops.CreateFile(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(fileops.Operation{Kind: fileops.OpCreate, Path: a}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.CreateFile(b); err != nil {
+		t.Fatal(err)
+	}
+	item, err := journal.Trash.Put(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(fileops.TrashOperation(journal.Trash, item)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Emptied outside the history, the trash can no longer be undone
+	if err := journal.Trash.Purge(item); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(); !errors.Is(err, fileops.ErrStaleOperation) {
+		t.Fatalf("got %v, want ErrStaleOperation", err)
+	}
+	if op, err := journal.Undo(); err != nil || op.Kind != fileops.OpCreate {
+		t.Errorf("the stale entry should not block older ones, got %v, %v", op, err)
+	}
+}
+
+func TestUIUndoAfterTrashRestore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+
+	for !strings.Contains(cursorLine(model.View()), "file1.txt") {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	typeKeys(model, "r")
+	typeLine(model, "notes.txt")
+	for !strings.Contains(cursorLine(model.View()), "file2.go") {
+		model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	typeKeys(model, "dy")
+	typeKeys(model, "T")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, err := os.Stat(filepath.Join(testDir, "file2.go")); err != nil {
+		t.Fatal("setup: file2.go should be restored from the trash view")
+	}
+
+	// The restore took care of the trash entry, so undo goes on to the rename
+	typeKeys(model, "T")
+	typeKeys(model, "u")
+	if _, err := os.Stat(filepath.Join(testDir, "file1.txt")); err != nil {
+		t.Errorf("undo should revert the rename:\n%s", model.View())
+	}
+}
+
+func TestJournalGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := t.TempDir()
+	journal := fileops.Journal{
+		Path:  filepath.Join(stateDir, "journal.json"),
+		Trash: fileops.Trash{Dir: filepath.Join(stateDir, "Trash")},
+	}
+	var ops []fileops.Operation
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := fileops.CreateFile(path); err != nil {
+			t.Fatal(err)
+		}
+		item, err := journal.Trash.Put(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, fileops.TrashOperation(journal.Trash, item))
+	}
+	if err := journal.Record(fileops.GroupOperation(ops)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A member that left the trash meanwhile is skipped, the others are restored
+	items, _ := journal.Trash.List()
+	for _, item := range items {
+		if item.Original == filepath.Join(tmpDir, "b.txt") {
+			journal.Trash.Purge(item)
+		}
+	}
+	if op, err := journal.Undo(); err != nil || op.Kind != fileops.OpGroup {
+		t.Fatalf("Undo = %v, %v; want the group", op, err)
+	}
+	for _, name := range []string{"a.txt", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("undoing the group should restore %s", name)
+		}
+	}
+
+	if op, err := journal.Redo(); err != nil || len(op.Ops) != 2 {
+		t.Fatalf("Redo = %v, %v; want the two restored members", op, err)
+	}
+	if items, _ := journal.Trash.List(); len(items) != 2 {
+		t.Errorf("redo should trash both members again, got %v", items)
+	}
+	if _, err := journal.Undo(); err != nil {
+		t.Errorf("the redone group should be undoable, got %v", err)
+	}
+}
+
+func TestJournalScopedToRoot(t *testing.T) {
+	stateDir := t.TempDir()
+	projects := t.TempDir()
+	first, second := filepath.Join(projects, "first"), filepath.Join(projects, "second")
+	journalFor := func(root string) fileops.Journal {
+		return fileops.Journal{
+			Path:  filepath.Join(stateDir, "journal.json"),
+			Trash: fileops.Trash{Dir: filepath.Join(stateDir, "Trash")},
+			Root:  root,
+		}
+	}
+	a, b := filepath.Join(first, "a.txt"), filepath.Join(second, "b.txt")
+	for _, path := range []string{a, b} {
+		if err := fileops.CreateFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := journalFor(filepath.Dir(path)).Record(fileops.Operation{Kind: fileops.OpCreate, Path: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The newest operation belongs to the other root, so it is left alone
+	if op, err := journalFor(first).Undo(); err != nil || op.Path != a {
+		t.Fatalf("Undo = %v, %v; want the create of a.txt", op, err)
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Error("undo should not touch operations outside the root")
+	}
+	if _, err := journalFor(first).Undo(); !errors.Is(err, fileops.ErrNothingToUndo) {
+		t.Errorf("only operations under the root should be undone, got %v", err)
+	}
+
+	// Recording under one root keeps what was undone under another redoable
+	if err := journalFor(second).Record(fileops.Operation{Kind: fileops.OpCreate, Path: filepath.Join(second, "c.txt")}); err != nil {
+		t.Fatal(err)
+	}
+	if op, err := journalFor(first).Redo(); err != nil || op.Path != a {
+		t.Errorf("Redo = %v, %v; want the create of a.txt", op, err)
+	}
+}
+
+func TestJournalConcurrentRecords(t *testing.T) {
+	stateDir := t.TempDir()
+	journal := fileops.Journal{Path: filepath.Join(stateDir, "journal.json")}
+
+	// Every instance re-reads the file under the lock, so no record is lost
+	const writers = 20
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			errs <- journal.Record(fileops.Operation{Kind: fileops.OpCreate, Path: filepath.Join(stateDir, fmt.Sprint(i))})
+		}(i)
+	}
+	for i := 0; i < writers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := 0
+	for {
+		if _, err := journal.Undo(); errors.Is(err, fileops.ErrNothingToUndo) {
+			break
+		}
+		count++
+	}
+	if count != writers {
+		t.Errorf("expected %d recorded operations, got %d", writers, count)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(stateDir, "journal.json.*")); len(leftovers) != 0 {
+		t.Errorf("the lock and temporary files should be removed, found %v", leftovers)
+	}
+}
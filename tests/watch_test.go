@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"dtree/internal/tree"
+	"dtree/internal/ui"
+	"dtree/internal/watch"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runWithTimeout runs a command that waits for a filesystem event
+func runWithTimeout(t *testing.T, cmd tea.Cmd) tea.Msg {
+	t.Helper()
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+	select {
+	case msg := <-result:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change was reported")
+		return nil
+	}
+}
+
+func TestWatcherReportsChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	sub := filepath.Join(tmpDir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := watch.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err := watcher.Watch([]string{tmpDir, sub}); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Watch([]string{tmpDir, filepath.Join(tmpDir, "missing")}); err == nil {
+		t.Error("watching a missing directory should fail")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	msg := runWithTimeout(t, func() tea.Msg { return <-watcher.Events() })
+	if dirs := msg.([]string); len(dirs) != 1 || dirs[0] != tmpDir {
+		t.Errorf("expected a change in %s, got %v", tmpDir, dirs)
+	}
+}
+
+func TestWatcherReportsNestedDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	sub := filepath.Join(tmpDir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := watch.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err := watcher.Watch([]string{tmpDir, sub}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{tmpDir, sub} {
+		if err := os.WriteFile(filepath.Join(dir, "new.txt"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each directory is refreshed on its own, so a parent does not hide its child
+	seen := make(map[string]bool)
+	for !seen[tmpDir] || !seen[sub] {
+		msg := runWithTimeout(t, func() tea.Msg { return <-watcher.Events() })
+		for _, dir := range msg.([]string) {
+			seen[dir] = true
+		}
+	}
+}
+
+func TestRefreshReadsOnlyOwnEntries(t *testing.T) {
+	testDir := setupTestFixture(t)
+	root := tree.Build(testDir, 2)
+	subdir := childNames(root)["subdir"]
+	if subdir == nil || !subdir.IsExpanded {
+		t.Fatal("subdir should start expanded")
+	}
+
+	for _, path := range []string{"top.txt", "subdir/added.txt"} {
+		if err := os.WriteFile(filepath.Join(testDir, path), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.Refresh()
+
+	children := childNames(root)
+	if _, ok := children["top.txt"]; !ok {
+		t.Error("Refresh should pick up new entries")
+	}
+	if children["subdir"] != subdir || !subdir.IsExpanded {
+		t.Error("Refresh should keep the existing node for subdir expanded")
+	}
+	if _, ok := childNames(subdir)["added.txt"]; ok {
+		t.Error("Refresh should not read subdirectories again")
+	}
+}
+
+func TestUIRefreshesOnChange(t *testing.T) {
+	testDir := setupTestFixture(t)
+	model := ui.New(tree.Build(testDir, 1), 1, testDir)
+	if err := model.SetWatch(true); err != nil {
+		t.Fatal(err)
+	}
+	defer model.SetWatch(false)
+
+	for !strings.Contains(cursorLine(model.View()), "file2.go") {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	cmd := model.Init()
+	if cmd == nil {
+		t.Fatal("Init should start listening for changes when watching")
+	}
+
+	if err := os.WriteFile(filepath.Join(testDir, "created.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(testDir, "file1.txt")); err != nil {
+		t.Fatal(err)
+	}
+	model.Update(runWithTimeout(t, cmd))
+
+	view := model.View()
+	if !strings.Contains(view, "created.txt") || strings.Contains(view, "file1.txt") {
+		t.Errorf("the tree should reflect the changes:\n%s", view)
+	}
+	if !strings.Contains(cursorLine(view), "file2.go") {
+		t.Errorf("the cursor should stay on file2.go, got %q", cursorLine(view))
+	}
+}
+
+func TestWatchDuringLoad(t *testing.T) {
+	tmpDir := setupLargeDir(t, 600)
+	model := ui.New(tree.Build(tmpDir, 1), 1, tmpDir)
+	if err := model.SetWatch(true); err != nil {
+		t.Fatal(err)
+	}
+	watchCmd := model.Init()
+
+	// Expand the large directory and receive only its first batch
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	var pending []tea.Cmd
+	for _, c := range cmd().(tea.BatchMsg) {
+		if c != nil {
+			_, next := model.Update(c())
+			pending = append(pending, next)
+		}
+	}
+
+	// A change in the parent arrives while the listing is still streaming
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, cmd = model.Update(runWithTimeout(t, watchCmd))
+	model.SetWatch(false)
+	// Finish the listing first; the pending spinner tick would otherwise keep ticking
+	for _, c := range append([]tea.Cmd{cmd}, pending...) {
+		drainCommands(t, model, c)
+	}
+
+	view := model.View()
+	if got := strings.Count(view, "── file0"); got != 600 {
+		t.Errorf("every entry should be listed once, got %d", got)
+	}
+	if !strings.Contains(view, "── new.txt") || strings.Contains(view, "load cancelled") {
+		t.Errorf("the parent should be refreshed and the listing completed:\n%s", view[:200])
+	}
+}